@@ -0,0 +1,94 @@
+// Command yapkeys manages the keys that sign yap's auth tokens.
+//
+//	yapkeys [-dir keys] list
+//	yapkeys [-dir keys] [-alg EdDSA|RS256] rotate
+//
+// rotate makes a new key, which starts signing once every server has had
+// time to load it, and deletes keys that nothing valid was signed with.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/l3njo/yap/keyring"
+)
+
+func main() {
+	dir := flag.String("dir", defaultDir(), "directory holding the keys")
+	alg := flag.String("alg", keyring.EdDSA, "algorithm of new keys, EdDSA or RS256")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: yapkeys [flags] list|rotate")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "list":
+		try(list(*dir))
+	case "rotate":
+		try(rotate(*dir, *alg))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// defaultDir is where the server looks for keys
+func defaultDir() string {
+	if dir := os.Getenv("JWT_KEYS"); dir != "" {
+		return dir
+	}
+	return "keys"
+}
+
+// list prints the keys in dir, marking the one that signs
+func list(dir string) error {
+	ring, err := keyring.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	signer, err := ring.Signer(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, k := range ring.Keys() {
+		mark := " "
+		if k.ID == signer.ID {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\t%s\t%s\n", mark, k.ID, k.Method.Alg(), k.Created.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// rotate adds a key for alg to dir and retires keys no valid token needs
+func rotate(dir, alg string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	k, err := keyring.Generate(dir, alg, now)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("added %s (%s), signing from %s\n", k.ID, alg, k.Created.Add(keyring.PublishDelay).Format(time.RFC3339))
+
+	retired, err := keyring.Retire(dir, keyring.TokenLife+keyring.PublishDelay, now)
+	for _, id := range retired {
+		fmt.Printf("retired %s\n", id)
+	}
+	return err
+}
+
+// try handles top-level errors
+func try(err error) {
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
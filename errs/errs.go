@@ -0,0 +1,148 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code is a stable, machine-readable error identifier
+type Code string
+
+// Codes shared by every endpoint
+const (
+	CodeInternal     Code = "internal"
+	CodeMalformed    Code = "malformed"
+	CodeInvalid      Code = "invalid"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+)
+
+// Codes for specific failures
+const (
+	CodeMailTaken         Code = "mail_taken"
+	CodeHandleTaken       Code = "handle_taken"
+	CodeBadCredentials    Code = "bad_credentials"
+	CodeSoleKeeper        Code = "sole_keeper"
+	CodeNotEditable       Code = "not_editable"
+	CodeStaleVersion      Code = "stale_version"
+	CodeBadTransition     Code = "bad_transition"
+	CodeRateLimited       Code = "rate_limited"
+	CodeLockedOut         Code = "locked_out"
+	CodeTwoFactor         Code = "two_factor_required"
+	CodeInsufficientScope Code = "insufficient_scope"
+)
+
+// Field codes used in validation details
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+)
+
+// Field describes a problem with a single request field
+type Field struct {
+	Name    string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error is an error that knows how it should be reported to clients
+type Error struct {
+	Status int
+	Code   Code
+	Detail string
+	Fields []Field
+	Err    error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.Status, e.Code)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause of an Error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithField adds a field-level detail to an Error
+func (e *Error) WithField(name, code, message string) *Error {
+	e.Fields = append(e.Fields, Field{Name: name, Code: code, Message: message})
+	return e
+}
+
+// New makes an Error with a status, code and human-readable detail
+func New(status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Wrap makes an Error with a status and code around a cause
+func Wrap(err error, status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail, Err: err}
+}
+
+// Internal wraps an unexpected failure
+func Internal(err error) *Error {
+	return Wrap(err, http.StatusInternalServerError, CodeInternal, "")
+}
+
+// NotFound wraps a missing record
+func NotFound(err error) *Error {
+	return Wrap(err, http.StatusNotFound, CodeNotFound, "")
+}
+
+// Forbidden reports that the caller may not perform an action
+func Forbidden() *Error {
+	return New(http.StatusForbidden, CodeForbidden, "")
+}
+
+// Invalid reports request fields that failed validation
+func Invalid(fields ...Field) *Error {
+	e := New(http.StatusBadRequest, CodeInvalid, "One or more fields are invalid.")
+	e.Fields = fields
+	return e
+}
+
+// From converts any error into an Error, treating unknown errors as internal.
+// An Error wrapped in another error, as by fmt.Errorf with %w, is found.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type     string  `json:"type"`
+	Title    string  `json:"title"`
+	Status   int     `json:"status"`
+	Detail   string  `json:"detail,omitempty"`
+	Instance string  `json:"instance,omitempty"`
+	Code     Code    `json:"code"`
+	Errors   []Field `json:"errors,omitempty"`
+}
+
+// Problem renders an Error as problem details for the request at instance.
+// Causes are never exposed to clients.
+func (e *Error) Problem(instance string) Problem {
+	return Problem{
+		Type:     "urn:yap:problem:" + string(e.Code),
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Detail,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
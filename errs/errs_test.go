@@ -0,0 +1,67 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestFrom(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   Code
+	}{
+		{
+			name:       "Typed Error Test",
+			err:        New(http.StatusConflict, CodeMailTaken, "taken"),
+			wantStatus: http.StatusConflict,
+			wantCode:   CodeMailTaken,
+		},
+		{
+			name:       "Wrapped Error Test",
+			err:        fmt.Errorf("reading user: %w", NotFound(cause)),
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeNotFound,
+		},
+		{
+			name:       "Plain Error Test",
+			err:        cause,
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Status != tt.wantStatus {
+				t.Errorf("From() status = %v, want %v", got.Status, tt.wantStatus)
+			}
+			if got.Code != tt.wantCode {
+				t.Errorf("From() code = %v, want %v", got.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestError_Problem(t *testing.T) {
+	err := Wrap(errors.New("secret"), http.StatusBadRequest, CodeInvalid, "bad").WithField("mail", FieldRequired, "")
+	want := Problem{
+		Type:     "urn:yap:problem:invalid",
+		Title:    "Bad Request",
+		Status:   http.StatusBadRequest,
+		Detail:   "bad",
+		Instance: "/users/join",
+		Code:     CodeInvalid,
+		Errors:   []Field{{Name: "mail", Code: FieldRequired}},
+	}
+
+	if got := err.Problem("/users/join"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Error.Problem() = %v, want %v", got, want)
+	}
+}
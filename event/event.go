@@ -0,0 +1,103 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Type names something that happened
+type Type string
+
+// Types of events published by yap
+const (
+	PostUpdated          Type = "post.updated"
+	PostSubmitted        Type = "post.submitted"
+	PostApproved         Type = "post.approved"
+	PostChangesRequested Type = "post.changes_requested"
+	PostPublished        Type = "post.published"
+	PostRetracted        Type = "post.retracted"
+	PostArchived         Type = "post.archived"
+	PostUnarchived       Type = "post.unarchived"
+	PostDeleted          Type = "post.deleted"
+	PostRestored         Type = "post.restored"
+	PostTransferred      Type = "post.transferred"
+	AuthorInvited        Type = "author.invited"
+	ReactionCreated      Type = "reaction.created"
+	ReactionUpdated      Type = "reaction.updated"
+	ReactionDeleted      Type = "reaction.deleted"
+	ReactionRestored     Type = "reaction.restored"
+	UserAssigned         Type = "user.assigned"
+)
+
+// NotificationCreated is published when a User is notified. It is private to
+// that User and so is not in Types, which webhooks may subscribe to.
+const NotificationCreated Type = "notification.created"
+
+// Types published when a User, with their Posts and Reactions, is deleted,
+// restored or erased. Their Data is the ID of the User. They are not in
+// Types, so webhooks are never told about an erased User.
+const (
+	UserDeleted  Type = "user.deleted"
+	UserRestored Type = "user.restored"
+	UserErased   Type = "user.erased"
+)
+
+// Types lists every event Type
+var Types = []Type{
+	PostUpdated, PostSubmitted, PostApproved, PostChangesRequested, PostPublished, PostRetracted,
+	PostArchived, PostUnarchived, PostDeleted, PostRestored, PostTransferred, AuthorInvited,
+	ReactionCreated, ReactionUpdated, ReactionDeleted, ReactionRestored,
+	UserAssigned,
+}
+
+// Public reports whether t is one of Types
+func (t Type) Public() bool {
+	for _, p := range Types {
+		if t == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is something that happened to a resource
+type Event struct {
+	ID    uuid.UUID   `json:"id"`
+	Type  Type        `json:"type"`
+	Actor uuid.UUID   `json:"actor"`
+	Time  time.Time   `json:"created_at"`
+	Data  interface{} `json:"data"`
+}
+
+// Handler reacts to published events
+type Handler func(Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+// New makes an Event of type t caused by actor
+func New(t Type, actor uuid.UUID, data interface{}) Event {
+	return Event{ID: uuid.NewV4(), Type: t, Actor: actor, Time: time.Now().UTC(), Data: data}
+}
+
+// Subscribe registers h to receive every published Event
+func Subscribe(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+}
+
+// Publish hands e to every subscribed Handler in turn.
+// Handlers may themselves publish events.
+func Publish(e Event) {
+	mu.RLock()
+	hs := handlers
+	mu.RUnlock()
+	for _, h := range hs {
+		h(e)
+	}
+}
@@ -3,7 +3,8 @@ package handler
 import (
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
 	"github.com/l3njo/yap/util"
 	"github.com/labstack/echo/v4"
@@ -18,203 +19,204 @@ type ArticlesResponse struct {
 
 // GetArticles handles the "/posts/articles" route.
 func GetArticles(c echo.Context) error {
-	resp, status := ArticlesResponse{}, 0
-	articles, status, err := model.ReadAllArticles()
+	articles, err := model.ReadAllArticles()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Articles = true, http.StatusText(status), articles
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ArticlesResponse{Response: ok(status), Articles: articles})
 }
 
 // GetPublicArticles handles the "/posts/articles/public" route.
 func GetPublicArticles(c echo.Context) error {
-	resp, status := ArticlesResponse{}, 0
-	articles, status, err := model.ReadAllArticles()
+	articles, err := model.ReadAllArticles()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	articles = util.FilterA(articles, func(a model.Article) bool {
 		return a.Release
 	})
 
-	resp.Status, resp.Message, resp.Articles = true, http.StatusText(status), articles
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ArticlesResponse{Response: ok(status), Articles: articles})
 }
 
 // GetArticleByID handles the "/posts/articles/:id" route.
 func GetArticleByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
 	article := &model.Article{}
-	article.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(article.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	status, err := article.Read()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	article.ID = id
+	if err := article.Read(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), article
-	return c.JSON(status, resp)
+	if err := model.NavigateSeries(&article.PostBase, false); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
 }
 
 // GetPublicArticleByID handles the "/posts/articles/public/:id" route.
 func GetPublicArticleByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
-	article := &model.Article{
-		PostBase: model.PostBase{
-			Base: model.Base{
-				ID: uuid.FromStringOrNil(c.Param("id")),
-			},
-			Release: true,
-		},
+	article := &model.Article{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	article.ID = id
+	if err := article.Read(); err != nil {
+		return err
 	}
 
-	if uuid.Equal(article.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !article.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
 	}
 
-	status, err := article.Read()
+	if err := model.NavigateSeries(&article.PostBase, true); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
+}
+
+// GetPublicArticleBySlug handles the "/posts/articles/public/by-slug/:slug" route.
+func GetPublicArticleBySlug(c echo.Context) error {
+	article := &model.Article{}
+	slug, err := model.ResolveSlug(article, c.Param("slug"))
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
+	}
+
+	if slug != c.Param("slug") {
+		return c.Redirect(http.StatusMovedPermanently, "/posts/articles/public/by-slug/"+slug)
+	}
+
+	if err := article.Read(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), article
-	return c.JSON(status, resp)
+	if !article.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
+	}
+
+	if err := model.NavigateSeries(&article.PostBase, true); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
 }
 
 // CreateArticle handles the "/posts/articles/create" route.
 func CreateArticle(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	article := &model.Article{}
-	if err := c.Bind(article); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	r := articleRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	if !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
+	article := &model.Article{}
+	r.apply(&article.PostBase)
+	article.Format, article.Content = r.Format, r.Content
 	article.Creator = claims.User
-	if status, err := article.Create(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := article.Create(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), article
-	return c.JSON(status, resp)
+	status := http.StatusCreated
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
 }
 
 // UpdateArticle handles the "/posts/articles/:id/update" route.
 func UpdateArticle(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-	resp, status := PostResponse{}, 0
-	article, a := &model.Article{}, &model.Article{}
-	if err := c.Bind(a); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	article, r := &model.Article{}, articleUpdateRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	article.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(article.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	article.ID = id
+	if err := article.Read(); err != nil {
+		return err
 	}
 
-	if status, err := article.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if ok, err := canWritePost(&article.PostBase, claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
 	}
 
-	if article.Creator != claims.User {
-		if (!article.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(article.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if err := checkEditable(&article.PostBase, claims); err != nil {
+		return err
 	}
 
-	article.PostBase, article.Content = a.PostBase, a.Content
-	status, err := article.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	r.apply(&article.PostBase)
+	article.Format, article.Content = r.Format, r.Content
+	if err := article.Update(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), article
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostUpdated, claims.User, article))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
 }
 
 // TransferArticle handles the "/posts/articles/:id/transfer" route.
 func TransferArticle(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
+	claims := claimsOf(c)
+	article, r := &model.Article{}, transferRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
 
-	resp, status := PostResponse{}, 0
-	article, a := &model.Article{}, &model.Article{}
-	if err := c.Bind(a); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	article.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(article.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	article.ID = id
+	if err := article.Read(); err != nil {
+		return err
 	}
 
-	if status, err := article.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !uuid.Equal(article.Creator, claims.User) && !canEditPost(&article.PostBase, claims) {
+		return errs.Forbidden()
 	}
 
-	if article.Creator != claims.User {
-		if (!article.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(article.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if uuid.Equal(article.Creator, r.Creator) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
-	if uuid.Equal(article.Creator, a.Creator) {
-		status = http.StatusNotModified
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := checkUserExists(r.Creator, "creator"); err != nil {
+		return err
 	}
 
-	article.Creator = a.Creator
-	status, err := article.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := model.TransferPost(article, r.Creator, actorOf(c)); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), article
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostTransferred, claims.User, article))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: article})
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	auditLimit     = 100
+	auditLimitMax  = 500
+	auditExportMax = 10000
+)
+
+// AuditResponse is a response containing a page of the audit log
+type AuditResponse struct {
+	Response
+	Entries []model.AuditEntry `json:"data"`
+	Next    *time.Time         `json:"next,omitempty"`
+}
+
+// actorOf returns who is making a request, for the audit log
+func actorOf(c echo.Context) model.Actor {
+	return model.Actor{User: claimsOf(c).User, IP: c.RealIP(), Agent: c.Request().UserAgent()}
+}
+
+// auditFilter reads the "actor", "action", "kind", "target", "since" and
+// "before" query parameters, and a "limit" of up to max.
+func auditFilter(c echo.Context, limit, max int) (model.AuditFilter, error) {
+	f := model.AuditFilter{
+		Action: model.AuditAction(c.QueryParam("action")),
+		Kind:   c.QueryParam("kind"),
+		Limit:  limit,
+	}
+
+	e := errs.Invalid()
+	for _, p := range []struct {
+		name string
+		id   *uuid.UUID
+	}{{"actor", &f.Actor}, {"target", &f.Target}} {
+		if s := c.QueryParam(p.name); s != "" {
+			if *p.id = uuid.FromStringOrNil(s); uuid.Equal(*p.id, uuid.Nil) {
+				e.WithField(p.name, errs.FieldInvalid, "Must be a UUID.")
+			}
+		}
+	}
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &f.Since}, {"before", &f.Before}} {
+		if s := c.QueryParam(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				e.WithField(p.name, errs.FieldInvalid, "Must be an RFC 3339 time.")
+			}
+			*p.t = t
+		}
+	}
+	if s := c.QueryParam("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > max {
+			e.WithField("limit", errs.FieldInvalid, "Must be between 1 and "+strconv.Itoa(max)+".")
+		}
+		f.Limit = n
+	}
+
+	if len(e.Fields) > 0 {
+		return f, e
+	}
+	return f, nil
+}
+
+// GetAuditLog handles the "/admin/audit" route.
+// Pages are newest first; pass "next" back as "before" for the page after.
+func GetAuditLog(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionAuditOps, nil) {
+		return errs.Forbidden()
+	}
+
+	f, err := auditFilter(c, auditLimit, auditLimitMax)
+	if err != nil {
+		return err
+	}
+
+	entries, err := model.ReadAuditLog(f)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	resp := AuditResponse{Response: ok(status), Entries: entries}
+	if len(entries) == f.Limit {
+		resp.Next = &entries[len(entries)-1].CreatedAt
+	}
+	return c.JSON(status, resp)
+}
+
+// ExportAuditLog handles the "/admin/audit/export" route.
+// It takes the same filters as GetAuditLog, up to auditExportMax entries.
+func ExportAuditLog(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionAuditOps, nil) {
+		return errs.Forbidden()
+	}
+
+	f, err := auditFilter(c, auditExportMax, auditExportMax)
+	if err != nil {
+		return err
+	}
+
+	entries, err := model.ReadAuditLog(f)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="audit.csv"`)
+	res.WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(res)
+	_ = w.Write([]string{"id", "created_at", "actor", "action", "kind", "target", "before", "after", "ip", "agent"})
+	for _, a := range entries {
+		_ = w.Write([]string{
+			a.ID.String(), a.CreatedAt.UTC().Format(time.RFC3339Nano), a.Actor.String(),
+			csvCell(string(a.Action)), csvCell(a.Kind), a.Target.String(),
+			csvCell(string(a.Before.RawMessage)), csvCell(string(a.After.RawMessage)),
+			csvCell(a.IP), csvCell(a.Agent),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// csvCell stops spreadsheets from reading s as a formula
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
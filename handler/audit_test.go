@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/labstack/echo/v4"
+)
+
+func TestAuditFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantLimit  int
+		wantFields []string
+	}{
+		{name: "Default Test", query: "", wantLimit: auditLimit},
+		{name: "Filters Test", query: "?action=user.assign&actor=6ba7b810-9dad-11d1-80b4-00c04fd430c8&since=2020-01-02T15:04:05Z&limit=7", wantLimit: 7},
+		{name: "Bad UUID Test", query: "?actor=nobody&target=6ba7b810", wantLimit: auditLimit, wantFields: []string{"actor", "target"}},
+		{name: "Bad Time Test", query: "?before=yesterday", wantLimit: auditLimit, wantFields: []string{"before"}},
+		{name: "Limit Too High Test", query: "?limit=501", wantLimit: 501, wantFields: []string{"limit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/admin/audit"+tt.query, nil), httptest.NewRecorder())
+			f, err := auditFilter(c, auditLimit, auditLimitMax)
+			if f.Limit != tt.wantLimit {
+				t.Errorf("auditFilter() limit = %v, want %v", f.Limit, tt.wantLimit)
+			}
+
+			var got []string
+			if err != nil {
+				for _, field := range errs.From(err).Fields {
+					got = append(got, field.Name)
+				}
+			}
+			if len(got) != len(tt.wantFields) {
+				t.Fatalf("auditFilter() fields = %v, want %v", got, tt.wantFields)
+			}
+			for i := range got {
+				if got[i] != tt.wantFields[i] {
+					t.Errorf("auditFilter() fields = %v, want %v", got, tt.wantFields)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "curl/7.68.0", want: "curl/7.68.0"},
+		{in: "=HYPERLINK(\"x\")", want: "'=HYPERLINK(\"x\")"},
+		{in: "@SUM(A1)", want: "'@SUM(A1)"},
+		{in: "-1", want: "'-1"},
+	}
+
+	for _, tt := range tests {
+		if got := csvCell(tt.in); got != tt.want {
+			t.Errorf("csvCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -2,158 +2,157 @@ package handler
 
 import (
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/keyring"
 	"github.com/l3njo/yap/model"
 	"github.com/labstack/echo/v4"
 	uuid "github.com/satori/go.uuid"
 )
 
 // JwtCustomClaims are custom claims extending default ones.
+// MFA is set on tokens issued after a second factor was checked.
+// Token is set when a personal access token authenticated the request.
 type JwtCustomClaims struct {
-	User uuid.UUID      `json:"user"`
-	Role model.UserRole `json:"role"`
+	User  uuid.UUID          `json:"user"`
+	Role  model.UserRole     `json:"role"`
+	MFA   bool               `json:"mfa,omitempty"`
+	Token *model.AccessToken `json:"-"`
 	jwt.StandardClaims
 }
 
-func createAuthString(user model.User) (string, error) {
+// Keys sign auth tokens and verify them by key ID.
+// Set it before serving.
+var Keys *keyring.Ring
+
+// createAuthString starts a Session for user from the request c and
+// returns the token that carries it
+func createAuthString(c echo.Context, user model.User, mfa bool) (string, error) {
+	now := time.Now()
+	s := model.Session{User: user.ID, Agent: c.Request().UserAgent(), IP: c.RealIP(), Expires: now.Add(keyring.TokenLife).UTC()}
+	if err := s.Create(); err != nil {
+		return "", err
+	}
+	return signAuth(user, mfa, s.ID, now)
+}
+
+// signAuth signs a token for user in session, issued at now
+func signAuth(user model.User, mfa bool, session uuid.UUID, now time.Time) (string, error) {
 	claims := &JwtCustomClaims{
 		User: user.ID,
 		Role: user.Role,
+		MFA:  mfa,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(),
+			Id:        session.String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(keyring.TokenLife).Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	authString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	key, err := Keys.Signer(now)
 	if err != nil {
-		return "", err
+		return "", errs.Internal(err)
+	}
+
+	authString, err := key.Sign(claims)
+	if err != nil {
+		return "", errs.Internal(err)
 	}
 
 	return authString, nil
 }
 
+// GetJWKS handles the "/.well-known/jwks.json" route.
+// Other services verify yap's auth tokens with these keys.
+func GetJWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, Keys.JWKS())
+}
+
 // JoinUser handles the "/users/join" route.
 func JoinUser(c echo.Context) error {
-	resp, status := UserResponse{}, 0
-	user, u := model.User{}, model.User{}
-	if err := c.Bind(&u); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user, r := model.User{}, joinRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
-	user.Name, user.Mail, user.Pass, user.Life = u.Name, u.Mail, u.Pass, u.Life
-	status, err := user.Create()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user.Handle, user.Name, user.Mail, user.Pass, user.Life = r.Handle, r.Name, r.Mail, r.Pass, r.Life
+	if err := user.Create(); err != nil {
+		return err
 	}
 
 	user.Pass = ""
-	authString, err := createAuthString(user)
+	authString, err := createAuthString(c, user, false)
 	if err != nil {
-		status = http.StatusInternalServerError
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	user.Auth = authString
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	status := http.StatusCreated
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
 }
 
 // AuthUser handles the "users/auth" route.
+// Users with two-factor authentication get a challenge to answer at
+// "/users/auth/2fa" instead of a token.
 func AuthUser(c echo.Context) error {
-	resp, status := UserResponse{}, 0
-	user := model.User{}
-	err := c.Bind(&user)
-	if err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	r := authRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
-	status, err = user.ValidateAuth()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user := model.User{Mail: r.Mail, Pass: r.Pass}
+	if err := tryLogin(c, &user); err != nil {
+		return err
 	}
 
-	status, err = user.TryAuth()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if user.TwoFactor {
+		return sendChallenge(c, user)
 	}
 
 	user.Pass = ""
-	authString, err := createAuthString(user)
+	authString, err := createAuthString(c, user, false)
 	if err != nil {
-		status = http.StatusInternalServerError
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	user.Auth = authString
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
 }
 
 // UpdatePass handles the "/users/me/change" route.
+// It logs the User out everywhere but the session that changed it, and
+// revokes their personal access tokens.
 func UpdatePass(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := UserResponse{}, 0
-	user, u := model.User{}, map[string]string{}
-	if err := c.Bind(&u); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	if u["current"] == "" || u["updated"] == "" {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	user, r := model.User{}, passRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	user.ID = claims.User
-	if uuid.Equal(user.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := user.Read(); err != nil {
+		return err
 	}
 
-	if status, err := user.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user.Pass = r.Current
+	if err := tryLogin(c, &user); err != nil {
+		return err
 	}
 
-	user.Pass = u["current"]
-	status, err := user.ValidateAuth()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user.Pass = r.Updated
+	if err := user.Update(); err != nil {
+		return err
 	}
 
-	status, err = user.TryAuth()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	user.Pass = u["updated"]
-	status, err = user.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if _, err := user.RevokeAccess(sessionOf(c), actorOf(c)); err != nil {
+		return err
 	}
 
 	user.Pass = ""
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
 }
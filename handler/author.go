@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthorResponse is a response containing one PostAuthor
+type AuthorResponse struct {
+	Response
+	model.PostAuthor `json:"data"`
+}
+
+// AuthorsResponse is a response containing a slice of PostAuthors
+type AuthorsResponse struct {
+	Response
+	Authors []model.PostAuthor `json:"data"`
+}
+
+// canWritePost reports whether the holder of claims may edit a Post:
+// its owners and co-authors may, as may anyone canEditPost allows.
+func canWritePost(post *model.PostBase, claims *JwtCustomClaims) (bool, error) {
+	if canEditPost(post, claims) {
+		return true, nil
+	}
+
+	role, err := model.AuthorRoleOf(post, claims.User)
+	return role == model.AuthorOwner || role == model.AuthorCoauthor, err
+}
+
+// canReadPost reports whether the holder of claims may read a Post:
+// anyone may once it is released, and before that anyone invited to work
+// on it may, reviewers included, as may anyone canEditPost allows.
+func canReadPost(post *model.PostBase, claims *JwtCustomClaims) (bool, error) {
+	if post.Release || canEditPost(post, claims) {
+		return true, nil
+	}
+
+	role, err := model.AuthorRoleOf(post, claims.User)
+	return role != "", err
+}
+
+// canManageAuthors reports whether the holder of claims may invite and remove
+// the authors of a Post: its owners may, as may anyone canEditPost allows.
+func canManageAuthors(post *model.PostBase, claims *JwtCustomClaims) (bool, error) {
+	if canEditPost(post, claims) {
+		return true, nil
+	}
+
+	role, err := model.AuthorRoleOf(post, claims.User)
+	return role == model.AuthorOwner, err
+}
+
+// GetPostAuthors handles the "/posts/:id/authors" route.
+// Only those canWritePost allows may see who is invited.
+func GetPostAuthors(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(id)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := canWritePost(post.Meta(), claimsOf(c)); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
+	}
+
+	authors, err := model.ReadPostAuthors(id)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, AuthorsResponse{Response: ok(status), Authors: authors})
+}
+
+// InvitePostAuthor handles the "/posts/:id/authors/invite" route.
+func InvitePostAuthor(c echo.Context) error {
+	claims := claimsOf(c)
+	r := authorRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(id)
+	if err != nil {
+		return err
+	}
+
+	if ok, err := canManageAuthors(post.Meta(), claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
+	}
+
+	if uuid.Equal(r.User, post.Meta().Creator) {
+		return errs.Invalid(errs.Field{Name: "user", Code: errs.FieldInvalid, Message: "The creator already owns this post."})
+	}
+
+	if err := checkUserExists(r.User, "user"); err != nil {
+		return err
+	}
+
+	author := model.PostAuthor{Post: id, User: r.User, Role: r.Role, Inviter: claims.User}
+	if err := author.Invite(); err != nil {
+		return err
+	}
+
+	if author.Accepted == nil {
+		event.Publish(event.New(event.AuthorInvited, claims.User, author))
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, AuthorResponse{Response: ok(status), PostAuthor: author})
+}
+
+// AcceptPostAuthor handles the "/posts/:id/authors/accept" route.
+func AcceptPostAuthor(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	author := model.PostAuthor{Post: id, User: claimsOf(c).User}
+	if err := author.Accept(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, AuthorResponse{Response: ok(status), PostAuthor: author})
+}
+
+// RemovePostAuthor handles the "/posts/:id/authors/:user/delete" route.
+// Authors may always remove themselves or decline an invitation.
+func RemovePostAuthor(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := paramID(c, "user")
+	if err != nil {
+		return err
+	}
+
+	if !uuid.Equal(user, claims.User) {
+		post, err := model.PeekPost(id)
+		if err != nil {
+			return err
+		}
+
+		if ok, err := canManageAuthors(post.Meta(), claims); err != nil {
+			return err
+		} else if !ok {
+			return errs.Forbidden()
+		}
+	}
+
+	author := model.PostAuthor{Post: id, User: user}
+	if err := author.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
@@ -3,7 +3,8 @@ package handler
 import (
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
 	"github.com/l3njo/yap/util"
 	"github.com/labstack/echo/v4"
@@ -18,204 +19,204 @@ type FlickersResponse struct {
 
 // GetFlickers handles the "/posts/flickers" route.
 func GetFlickers(c echo.Context) error {
-	resp, status := FlickersResponse{}, 0
-	flickers, status, err := model.ReadAllFlickers()
+	flickers, err := model.ReadAllFlickers()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Flickers = true, http.StatusText(status), flickers
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, FlickersResponse{Response: ok(status), Flickers: flickers})
 }
 
 // GetPublicFlickers handles the "/posts/flickers/public" route.
 func GetPublicFlickers(c echo.Context) error {
-	resp, status := FlickersResponse{}, 0
-	flickers, status, err := model.ReadAllFlickers()
+	flickers, err := model.ReadAllFlickers()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	flickers = util.FilterF(flickers, func(f model.Flicker) bool {
 		return f.Release
 	})
 
-	resp.Status, resp.Message, resp.Flickers = true, http.StatusText(status), flickers
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, FlickersResponse{Response: ok(status), Flickers: flickers})
 }
 
 // GetFlickerByID handles the "/posts/flickers/:id" route.
 func GetFlickerByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
 	flicker := &model.Flicker{}
-	flicker.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(flicker.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	status, err := flicker.Read()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	flicker.ID = id
+	if err := flicker.Read(); err != nil {
+		return err
+	}
+
+	if err := model.NavigateSeries(&flicker.PostBase, false); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), flicker
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
 }
 
 // GetPublicFlickerByID handles the "/posts/flickers/public/:id" route.
 func GetPublicFlickerByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
-	flicker := &model.Flicker{
-		PostBase: model.PostBase{
-			Base: model.Base{
-				ID: uuid.FromStringOrNil(c.Param("id")),
-			},
-			Release: true,
-		},
+	flicker := &model.Flicker{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	flicker.ID = id
+	if err := flicker.Read(); err != nil {
+		return err
 	}
 
-	if uuid.Equal(flicker.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !flicker.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
 	}
 
-	status, err := flicker.Read()
+	if err := model.NavigateSeries(&flicker.PostBase, true); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
+}
+
+// GetPublicFlickerBySlug handles the "/posts/flickers/public/by-slug/:slug" route.
+func GetPublicFlickerBySlug(c echo.Context) error {
+	flicker := &model.Flicker{}
+	slug, err := model.ResolveSlug(flicker, c.Param("slug"))
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
+	}
+
+	if slug != c.Param("slug") {
+		return c.Redirect(http.StatusMovedPermanently, "/posts/flickers/public/by-slug/"+slug)
+	}
+
+	if err := flicker.Read(); err != nil {
+		return err
+	}
+
+	if !flicker.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
+	}
+
+	if err := model.NavigateSeries(&flicker.PostBase, true); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), flicker
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
 }
 
 // CreateFlicker handles the "/posts/flickers/create" route.
 func CreateFlicker(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	flicker := &model.Flicker{}
-	if err := c.Bind(flicker); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	r := flickerRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	if !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
+	flicker := &model.Flicker{}
+	r.apply(&flicker.PostBase)
+	flicker.Content, flicker.Caption = r.Content, r.Caption
 	flicker.Creator = claims.User
-	if status, err := flicker.Create(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := flicker.Create(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), flicker
-	return c.JSON(status, resp)
+	status := http.StatusCreated
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
 }
 
 // UpdateFlicker handles the "/posts/flickers/:id/update" route.
 func UpdateFlicker(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
+	claims := claimsOf(c)
+	flicker, r := &model.Flicker{}, flickerUpdateRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
 
-	resp, status := PostResponse{}, 0
-	flicker, f := &model.Flicker{}, &model.Flicker{}
-	if err := c.Bind(f); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	flicker.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(flicker.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	flicker.ID = id
+	if err := flicker.Read(); err != nil {
+		return err
 	}
 
-	if status, err := flicker.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if ok, err := canWritePost(&flicker.PostBase, claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
 	}
 
-	if flicker.Creator != claims.User {
-		if (!flicker.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(flicker.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if err := checkEditable(&flicker.PostBase, claims); err != nil {
+		return err
 	}
 
-	flicker.PostBase, flicker.Content, flicker.Caption = f.PostBase, f.Content, f.Caption
-	status, err := flicker.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	r.apply(&flicker.PostBase)
+	flicker.Content, flicker.Caption = r.Content, r.Caption
+	if err := flicker.Update(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), flicker
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostUpdated, claims.User, flicker))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
 }
 
 // TransferFlicker handles the "/posts/flickers/:id/transfer" route.
 func TransferFlicker(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
+	claims := claimsOf(c)
+	flicker, r := &model.Flicker{}, transferRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
 
-	resp, status := PostResponse{}, 0
-	flicker, f := &model.Flicker{}, &model.Flicker{}
-	if err := c.Bind(f); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	flicker.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(flicker.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	flicker.ID = id
+	if err := flicker.Read(); err != nil {
+		return err
 	}
 
-	if status, err := flicker.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !uuid.Equal(flicker.Creator, claims.User) && !canEditPost(&flicker.PostBase, claims) {
+		return errs.Forbidden()
 	}
 
-	if flicker.Creator != claims.User {
-		if (!flicker.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(flicker.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if uuid.Equal(flicker.Creator, r.Creator) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
-	if uuid.Equal(flicker.Creator, f.Creator) {
-		status = http.StatusNotModified
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := checkUserExists(r.Creator, "creator"); err != nil {
+		return err
 	}
 
-	flicker.Creator = f.Creator
-	status, err := flicker.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := model.TransferPost(flicker, r.Creator, actorOf(c)); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), flicker
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostTransferred, claims.User, flicker))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: flicker})
 }
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Feed paging
+const (
+	feedLimit    = 20
+	feedLimitMax = 100
+)
+
+// FollowResponse is a response containing one Follow
+type FollowResponse struct {
+	Response
+	model.Follow `json:"data"`
+}
+
+// FollowsResponse is a response containing a slice of Follows
+type FollowsResponse struct {
+	Response
+	Follows []model.Follow `json:"data"`
+}
+
+// FeedResponse is a page of a User's feed.
+// Next is the "before" cursor of the following page, if there may be one.
+type FeedResponse struct {
+	Response
+	Posts  []model.Post `json:"data"`
+	Next   *time.Time   `json:"next,omitempty"`
+	NextID *uuid.UUID   `json:"next_id,omitempty"`
+}
+
+// FollowUser handles the "/users/restricted/:id/follow" route.
+func FollowUser(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if uuid.Equal(id, claims.User) {
+		return errs.Invalid(errs.Field{Name: "id", Code: errs.FieldInvalid, Message: "You cannot follow yourself."})
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); err != nil {
+		return err
+	}
+
+	follow := model.Follow{User: claims.User, Kind: model.FollowUser, Target: id.String()}
+	if err := follow.Create(); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, FollowResponse{Response: ok(status), Follow: follow})
+}
+
+// UnfollowUser handles the "/users/restricted/:id/unfollow" route.
+func UnfollowUser(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	follow := model.Follow{User: claims.User, Kind: model.FollowUser, Target: id.String()}
+	if err := follow.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// GetUserFollowers handles the "/users/:id/followers" route.
+func GetUserFollowers(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	users, err := model.ReadFollowers(id)
+	if err != nil {
+		return err
+	}
+
+	return profilesJSON(c, users)
+}
+
+// GetUserFollowing handles the "/users/:id/following" route.
+func GetUserFollowing(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	users, err := model.ReadFollowing(id)
+	if err != nil {
+		return err
+	}
+
+	return profilesJSON(c, users)
+}
+
+// GetFollows handles the "/users/restricted/me/follows" route.
+func GetFollows(c echo.Context) error {
+	follows, err := model.ReadUserFollows(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, FollowsResponse{Response: ok(status), Follows: follows})
+}
+
+// CreateFollow handles the "/users/restricted/me/follows/create" route.
+func CreateFollow(c echo.Context) error {
+	r := followRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	follow := model.Follow{User: claimsOf(c).User, Kind: r.Kind, Target: r.Target}
+	if err := follow.Create(); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, FollowResponse{Response: ok(status), Follow: follow})
+}
+
+// DeleteFollow handles the "/users/restricted/me/follows/:id/delete" route.
+func DeleteFollow(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	follow := model.Follow{Base: model.Base{ID: id}, User: claimsOf(c).User}
+	if err := follow.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// GetFeed handles the "/users/restricted/me/feed" route.
+// It takes a cursor of an RFC 3339 "before" time and a "before_id" post ID,
+// as returned in "next" and "next_id", and a "limit" of up to feedLimitMax.
+func GetFeed(c echo.Context) error {
+	before := time.Now().UTC()
+	if s := c.QueryParam("before"); s != "" {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return errs.Invalid(errs.Field{Name: "before", Code: errs.FieldInvalid, Message: "Must be an RFC 3339 time."})
+		}
+		before = t
+	}
+
+	beforeID := uuid.Nil
+	if s := c.QueryParam("before_id"); s != "" {
+		id, err := uuid.FromString(s)
+		if err != nil {
+			return errs.Invalid(errs.Field{Name: "before_id", Code: errs.FieldInvalid, Message: "Must be a UUID."})
+		}
+		beforeID = id
+	}
+
+	limit := feedLimit
+	if s := c.QueryParam("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > feedLimitMax {
+			return errs.Invalid(errs.Field{Name: "limit", Code: errs.FieldInvalid, Message: "Must be between 1 and " + strconv.Itoa(feedLimitMax) + "."})
+		}
+		limit = n
+	}
+
+	posts, err := model.ReadFeed(claimsOf(c).User, before, beforeID, limit)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	resp := FeedResponse{Response: ok(status), Posts: posts}
+	if len(posts) == limit {
+		last := posts[len(posts)-1].Meta()
+		resp.Next, resp.NextID = last.Released, &last.ID
+	}
+	return c.JSON(status, resp)
+}
+
+// usersJSON writes users without their password hashes
+func usersJSON(c echo.Context, users []model.User) error {
+	status := http.StatusOK
+	resp := UsersResponse{Response: ok(status), Users: []model.User{}}
+	for _, user := range users {
+		user.Pass = ""
+		resp.Users = append(resp.Users, user)
+	}
+
+	return c.JSON(status, resp)
+}
+
+// profilesJSON writes the public Profiles of users
+func profilesJSON(c echo.Context, users []model.User) error {
+	status := http.StatusOK
+	resp := ProfilesResponse{Response: ok(status), Profiles: []model.Profile{}}
+	for _, user := range users {
+		resp.Profiles = append(resp.Profiles, user.Profile())
+	}
+
+	return c.JSON(status, resp)
+}
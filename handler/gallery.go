@@ -3,7 +3,8 @@ package handler
 import (
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
 	"github.com/l3njo/yap/util"
 	"github.com/labstack/echo/v4"
@@ -18,204 +19,204 @@ type GalleriesResponse struct {
 
 // GetGalleries handles the "/posts/galleries" route.
 func GetGalleries(c echo.Context) error {
-	resp, status := GalleriesResponse{}, 0
-	galleries, status, err := model.ReadAllGalleries()
+	galleries, err := model.ReadAllGalleries()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Galleries = true, http.StatusText(status), galleries
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, GalleriesResponse{Response: ok(status), Galleries: galleries})
 }
 
 // GetPublicGalleries handles the "/posts/galleries/public" route.
 func GetPublicGalleries(c echo.Context) error {
-	resp, status := GalleriesResponse{}, 0
-	galleries, status, err := model.ReadAllGalleries()
+	galleries, err := model.ReadAllGalleries()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	galleries = util.FilterG(galleries, func(g model.Gallery) bool {
 		return g.Release
 	})
 
-	resp.Status, resp.Message, resp.Galleries = true, http.StatusText(status), galleries
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, GalleriesResponse{Response: ok(status), Galleries: galleries})
 }
 
 // GetGalleryByID handles the "/posts/galleries/:id" route.
 func GetGalleryByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
 	gallery := &model.Gallery{}
-	gallery.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(gallery.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	status, err := gallery.Read()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	gallery.ID = id
+	if err := gallery.Read(); err != nil {
+		return err
+	}
+
+	if err := model.NavigateSeries(&gallery.PostBase, false); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), gallery
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
 }
 
 // GetPublicGalleryByID handles the "/posts/galleries/public/:id" route.
 func GetPublicGalleryByID(c echo.Context) error {
-	resp, status := PostResponse{}, 0
-	gallery := &model.Gallery{
-		PostBase: model.PostBase{
-			Base: model.Base{
-				ID: uuid.FromStringOrNil(c.Param("id")),
-			},
-			Release: true,
-		},
+	gallery := &model.Gallery{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	gallery.ID = id
+	if err := gallery.Read(); err != nil {
+		return err
 	}
 
-	if uuid.Equal(gallery.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !gallery.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
 	}
 
-	status, err := gallery.Read()
+	if err := model.NavigateSeries(&gallery.PostBase, true); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
+}
+
+// GetPublicGalleryBySlug handles the "/posts/galleries/public/by-slug/:slug" route.
+func GetPublicGalleryBySlug(c echo.Context) error {
+	gallery := &model.Gallery{}
+	slug, err := model.ResolveSlug(gallery, c.Param("slug"))
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
+	}
+
+	if slug != c.Param("slug") {
+		return c.Redirect(http.StatusMovedPermanently, "/posts/galleries/public/by-slug/"+slug)
+	}
+
+	if err := gallery.Read(); err != nil {
+		return err
+	}
+
+	if !gallery.Release {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
+	}
+
+	if err := model.NavigateSeries(&gallery.PostBase, true); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), gallery
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
 }
 
 // CreateGallery handles the "/posts/galleries/create" route.
 func CreateGallery(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	gallery := &model.Gallery{}
-	if err := c.Bind(gallery); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	r := galleryRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	if !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
+	gallery := &model.Gallery{}
+	r.apply(&gallery.PostBase)
+	gallery.Content, gallery.Caption = r.Content, r.Caption
 	gallery.Creator = claims.User
-	if status, err := gallery.Create(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := gallery.Create(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), gallery
-	return c.JSON(status, resp)
+	status := http.StatusCreated
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
 }
 
 // UpdateGallery handles the "/posts/galleries/:id/update" route.
 func UpdateGallery(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
+	claims := claimsOf(c)
+	gallery, r := &model.Gallery{}, galleryUpdateRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
 
-	resp, status := PostResponse{}, 0
-	gallery, g := &model.Gallery{}, &model.Gallery{}
-	if err := c.Bind(g); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	gallery.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(gallery.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	gallery.ID = id
+	if err := gallery.Read(); err != nil {
+		return err
 	}
 
-	if status, err := gallery.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if ok, err := canWritePost(&gallery.PostBase, claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
 	}
 
-	if gallery.Creator != claims.User {
-		if (!gallery.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(gallery.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if err := checkEditable(&gallery.PostBase, claims); err != nil {
+		return err
 	}
 
-	gallery.PostBase, gallery.Content, gallery.Caption = g.PostBase, g.Content, g.Caption
-	status, err := gallery.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	r.apply(&gallery.PostBase)
+	gallery.Content, gallery.Caption = r.Content, r.Caption
+	if err := gallery.Update(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), gallery
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostUpdated, claims.User, gallery))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
 }
 
 // TransferGallery handles the "/posts/galleries/:id/transfer" route.
 func TransferGallery(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
+	claims := claimsOf(c)
+	gallery, r := &model.Gallery{}, transferRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
 
-	resp, status := PostResponse{}, 0
-	gallery, g := &model.Gallery{}, &model.Gallery{}
-	if err := c.Bind(g); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	gallery.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(gallery.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	gallery.ID = id
+	if err := gallery.Read(); err != nil {
+		return err
 	}
 
-	if status, err := gallery.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !uuid.Equal(gallery.Creator, claims.User) && !canEditPost(&gallery.PostBase, claims) {
+		return errs.Forbidden()
 	}
 
-	if gallery.Creator != claims.User {
-		if (!gallery.Release && !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)) ||
-			(gallery.Release && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)) {
-			status = http.StatusForbidden
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
+	if uuid.Equal(gallery.Creator, r.Creator) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
-	if uuid.Equal(gallery.Creator, g.Creator) {
-		status = http.StatusNotModified
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := checkUserExists(r.Creator, "creator"); err != nil {
+		return err
 	}
 
-	gallery.Creator = g.Creator
-	status, err := gallery.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := model.TransferPost(gallery, r.Creator, actorOf(c)); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), gallery
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.PostTransferred, claims.User, gallery))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: gallery})
 }
@@ -3,7 +3,10 @@ package handler
 import (
 	"net/http"
 
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
 	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
 )
 
 // Response is the base response type
@@ -16,3 +19,75 @@ type Response struct {
 func AppController(c echo.Context) error {
 	return c.String(http.StatusOK, "Welcome to the Yap API!!\n")
 }
+
+// ErrorHandler renders every error as RFC 7807 problem details.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	e := errs.From(err)
+	if he, ok := err.(*echo.HTTPError); ok {
+		e = fromHTTPError(he)
+	}
+
+	if e.Status >= http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		_ = c.NoContent(e.Status)
+		return
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/problem+json")
+	_ = c.JSON(e.Status, e.Problem(c.Request().URL.Path))
+}
+
+// fromHTTPError maps errors raised by echo and its middleware to an errs.Error
+func fromHTTPError(he *echo.HTTPError) *errs.Error {
+	code := errs.CodeInternal
+	switch he.Code {
+	case http.StatusBadRequest:
+		code = errs.CodeMalformed
+	case http.StatusUnauthorized:
+		code = errs.CodeUnauthorized
+	case http.StatusForbidden:
+		code = errs.CodeForbidden
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		code = errs.CodeNotFound
+	}
+
+	e := errs.Wrap(he.Internal, he.Code, code, "")
+	if msg, ok := he.Message.(string); ok && he.Code < http.StatusInternalServerError {
+		e.Detail = msg
+	}
+	return e
+}
+
+// claimsOf returns the claims of the JWT that authenticated a request
+func claimsOf(c echo.Context) *JwtCustomClaims {
+	return c.Get("user").(*jwt.Token).Claims.(*JwtCustomClaims)
+}
+
+// paramID parses the UUID in path parameter name
+func paramID(c echo.Context, name string) (uuid.UUID, error) {
+	id := uuid.FromStringOrNil(c.Param(name))
+	if uuid.Equal(id, uuid.Nil) {
+		return id, errs.Invalid(errs.Field{Name: name, Code: errs.FieldInvalid, Message: "Must be a UUID."})
+	}
+	return id, nil
+}
+
+// bind decodes a request body into the DTO i and validates it
+func bind(c echo.Context, i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		return errs.Wrap(err, http.StatusBadRequest, errs.CodeMalformed, "Request body could not be decoded.")
+	}
+	return c.Validate(i)
+}
+
+// ok builds the base response for a successful request
+func ok(status int) Response {
+	return Response{Status: true, Message: http.StatusText(status)}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/keyring"
+	"github.com/labstack/echo/v4"
+)
+
+// useTestKeys points Keys at a fresh keyring for the rest of the test
+func useTestKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Keys, err = keyring.Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	os.RemoveAll(dir)
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   errs.Code
+		wantFields int
+	}{
+		{
+			name:       "Typed Error Test",
+			err:        errs.Invalid(errs.Field{Name: "mail", Code: errs.FieldRequired}),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   errs.CodeInvalid,
+			wantFields: 1,
+		},
+		{
+			name:       "Echo Error Test",
+			err:        echo.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   errs.CodeNotFound,
+		},
+		{
+			name:       "Plain Error Test",
+			err:        errors.New("database is down"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   errs.CodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users/join", nil), rec)
+			ErrorHandler(tt.err, c)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ErrorHandler() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); got != "application/problem+json" {
+				t.Errorf("ErrorHandler() content type = %v, want application/problem+json", got)
+			}
+
+			problem := errs.Problem{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("ErrorHandler() code = %v, want %v", problem.Code, tt.wantCode)
+			}
+			if len(problem.Errors) != tt.wantFields {
+				t.Errorf("ErrorHandler() errors = %v, want %v", len(problem.Errors), tt.wantFields)
+			}
+			if problem.Instance != "/users/join" {
+				t.Errorf("ErrorHandler() instance = %v, want /users/join", problem.Instance)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/limit"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+)
+
+// LimitStore keeps the rate limits and login failures of every route.
+// Replace it before serving to share them between processes.
+var LimitStore limit.Store = limit.NewMemoryStore()
+
+// Rates of the limited routes
+var (
+	authRate     = limit.Rate{Burst: 10, Every: 6 * time.Second}  // Logins per IP
+	accountRate  = limit.Rate{Burst: 5, Every: time.Minute}       // Logins per account
+	joinRate     = limit.Rate{Burst: 5, Every: 12 * time.Minute}  // Registrations per IP
+	reactionRate = limit.Rate{Burst: 10, Every: 30 * time.Second} // Reactions per user
+)
+
+// loginLockout locks an account after five failed logins in a row,
+// for a minute and then twice as long after each further failure
+var loginLockout = limit.Lockout{Threshold: 5, Base: time.Minute, Max: time.Hour, Forget: 24 * time.Hour}
+
+// tooMany reports that a caller must wait before trying again
+func tooMany(c echo.Context, wait time.Duration, code errs.Code, detail string) error {
+	c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return errs.New(http.StatusTooManyRequests, code, detail)
+}
+
+// limitBy limits requests to r for each key, in a bucket named name
+func limitBy(name string, r limit.Rate, key func(echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if wait := LimitStore.Take(name+":"+key(c), r, time.Now()); wait > 0 {
+				return tooMany(c, wait, errs.CodeRateLimited, "Too many requests. Try again later.")
+			}
+			return next(c)
+		}
+	}
+}
+
+// IPExtractor finds the caller's address for byIP, audit entries and
+// Sessions. X-Forwarded-For is only believed from the comma-separated
+// proxy ranges in trusted; with none, the peer address is used.
+func IPExtractor(trusted string) (echo.IPExtractor, error) {
+	if strings.TrimSpace(trusted) == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range strings.Split(trusted, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// byIP keys a limit by the caller's address, as found by IPExtractor
+func byIP(c echo.Context) string {
+	return c.RealIP()
+}
+
+// byUser keys a limit by the authenticated caller
+func byUser(c echo.Context) string {
+	return claimsOf(c).User.String()
+}
+
+// tryLogin checks the credentials in u like TryAuth, but throttles each
+// account and locks it out after repeated failures
+func tryLogin(c echo.Context, u *model.User) error {
+	account, now := strings.ToLower(u.Mail), time.Now()
+	if wait := LimitStore.Take("account:"+account, accountRate, now); wait > 0 {
+		return tooMany(c, wait, errs.CodeRateLimited, "Too many logins to this account. Try again later.")
+	}
+	if left := loginLockout.Locked(LimitStore, "login:"+account, now); left > 0 {
+		return tooMany(c, left, errs.CodeLockedOut, "Too many failed logins to this account. Try again later.")
+	}
+
+	if err := u.TryAuth(); err != nil {
+		if errs.From(err).Code == errs.CodeBadCredentials {
+			loginLockout.Fail(LimitStore, "login:"+account, now)
+		}
+		return err
+	}
+
+	LimitStore.Clear("login:" + account)
+	return nil
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/l3njo/yap/limit"
+	"github.com/labstack/echo/v4"
+)
+
+func TestLimitBy(t *testing.T) {
+	LimitStore = limit.NewMemoryStore()
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limitBy("test", limit.Rate{Burst: 2, Every: time.Minute}, byIP))
+
+	codes := []int{}
+	rec := httptest.NewRecorder()
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("limitBy() codes = %v, want [200 200 429]", codes)
+	}
+	if got := rec.Header().Get(echo.HeaderRetryAfter); got != "60" {
+		t.Errorf("limitBy() Retry-After = %q, want %q", got, "60")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("limitBy() for another IP = %v, want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted string
+		remote  string
+		forged  string
+		want    string
+	}{
+		{name: "Forged Header Test", remote: "192.0.2.1:1234", forged: "203.0.113.9", want: "192.0.2.1"},
+		{name: "Trusted Proxy Test", trusted: "10.0.0.0/8", remote: "10.0.0.2:1234", forged: "203.0.113.9", want: "203.0.113.9"},
+		{name: "Untrusted Proxy Test", trusted: "10.0.0.0/8", remote: "192.0.2.1:1234", forged: "203.0.113.9", want: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extract, err := IPExtractor(tt.trusted)
+			if err != nil {
+				t.Fatalf("IPExtractor() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set(echo.HeaderXForwardedFor, tt.forged)
+			req.Header.Set(echo.HeaderXRealIP, tt.forged)
+			if got := extract(req); got != tt.want {
+				t.Errorf("IPExtractor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := IPExtractor("not a range"); err == nil {
+		t.Error("IPExtractor() of a bad range error = nil, want one")
+	}
+}
+
+func TestLimitByForgedIP(t *testing.T) {
+	LimitStore = limit.NewMemoryStore()
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.IPExtractor, _ = IPExtractor("")
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, limitBy("test", limit.Rate{Burst: 1, Every: time.Minute}, byIP))
+
+	codes := []int{}
+	for _, forged := range []string{"203.0.113.1", "203.0.113.2"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(echo.HeaderXForwardedFor, forged)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[1] != http.StatusTooManyRequests {
+		t.Errorf("limitBy() codes with forged headers = %v, want [200 429]", codes)
+	}
+}
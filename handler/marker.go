@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/marker"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/related"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Marker suggestions
+const (
+	suggestLimit    = 10
+	suggestLimitMax = 50
+)
+
+// MarkersResponse is a response containing a slice of MarkerCounts
+type MarkersResponse struct {
+	Response
+	Markers []model.MarkerCount `json:"data"`
+}
+
+// PostsResponse is a response containing Posts of any pattern
+type PostsResponse struct {
+	Response
+	Posts []model.Post `json:"data"`
+}
+
+// MergeResponse reports how many Posts a marker merge changed
+type MergeResponse struct {
+	Response
+	Changed int `json:"changed"`
+}
+
+// markerParam reads the marker in the ":name" path parameter
+func markerParam(c echo.Context) (string, error) {
+	name, err := url.PathUnescape(c.Param("name"))
+	if err != nil {
+		return "", errs.Invalid(errs.Field{Name: "name", Code: errs.FieldInvalid, Message: "Must be a URL-escaped marker."})
+	}
+	return name, nil
+}
+
+// GetMarkers handles the "/markers" route.
+func GetMarkers(c echo.Context) error {
+	markers, err := model.ReadMarkers("", 0)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, MarkersResponse{Response: ok(status), Markers: markers})
+}
+
+// SuggestMarkers handles the "/markers/suggest" route.
+// It completes the "q" query parameter to up to "limit" markers.
+func SuggestMarkers(c echo.Context) error {
+	limit := suggestLimit
+	if s := c.QueryParam("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > suggestLimitMax {
+			return errs.Invalid(errs.Field{Name: "limit", Code: errs.FieldInvalid, Message: "Must be between 1 and " + strconv.Itoa(suggestLimitMax) + "."})
+		}
+		limit = n
+	}
+
+	markers, err := model.ReadMarkers(marker.Normalize(c.QueryParam("q")), limit)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, MarkersResponse{Response: ok(status), Markers: markers})
+}
+
+// GetMarkerPosts handles the "/markers/:name/posts" route.
+func GetMarkerPosts(c echo.Context) error {
+	name, err := markerParam(c)
+	if err != nil {
+		return err
+	}
+
+	posts, err := model.ReadMarkerPosts(name)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostsResponse{Response: ok(status), Posts: posts})
+}
+
+// RenameMarker handles the "/markers/restricted/:name/rename" route.
+func RenameMarker(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionMarkerOps, nil) {
+		return errs.Forbidden()
+	}
+
+	name, err := markerParam(c)
+	if err != nil {
+		return err
+	}
+
+	r := markerRenameRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return mergeMarkers(c, []string{name}, r.To)
+}
+
+// MergeMarkers handles the "/markers/restricted/merge" route.
+func MergeMarkers(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionMarkerOps, nil) {
+		return errs.Forbidden()
+	}
+
+	r := markerMergeRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return mergeMarkers(c, r.From, r.To)
+}
+
+// mergeMarkers folds from into to and reports how many Posts changed
+func mergeMarkers(c echo.Context, from []string, to string) error {
+	if marker.Normalize(to) == "" {
+		return errs.Invalid(errs.Field{Name: "to", Code: errs.FieldInvalid, Message: "Must contain a letter or digit."})
+	}
+
+	changed, err := model.MergeMarkers(from, to)
+	if err != nil {
+		return err
+	}
+
+	// Merges rewrite posts without publishing events
+	related.Reset()
+
+	status := http.StatusAccepted
+	return c.JSON(status, MergeResponse{Response: ok(status), Changed: changed})
+}
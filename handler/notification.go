@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationsResponse is a response containing a slice of Notifications
+type NotificationsResponse struct {
+	Response
+	Unread        int                  `json:"unread"`
+	Notifications []model.Notification `json:"data"`
+}
+
+// NotificationResponse is a response containing one Notification
+type NotificationResponse struct {
+	Response
+	model.Notification `json:"data"`
+}
+
+// PreferencesResponse is a response containing notification preferences
+type PreferencesResponse struct {
+	Response
+	Preferences map[model.NotificationKind]bool `json:"data"`
+}
+
+// GetNotifications handles the "/users/restricted/me/notifications" route.
+func GetNotifications(c echo.Context) error {
+	claims := claimsOf(c)
+	unreadOnly := c.QueryParam("unread") == "true"
+	notifications, unread, err := model.ReadUserNotifications(claims.User, unreadOnly, 50)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, NotificationsResponse{Response: ok(status), Unread: unread, Notifications: notifications})
+}
+
+// ReadNotification handles the "/users/restricted/me/notifications/:id/read" route.
+func ReadNotification(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	notification := model.Notification{Base: model.Base{ID: id}, User: claims.User}
+	if err := notification.MarkRead(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, NotificationResponse{Response: ok(status), Notification: notification})
+}
+
+// ReadAllNotifications handles the "/users/restricted/me/notifications/read" route.
+func ReadAllNotifications(c echo.Context) error {
+	if err := model.MarkAllNotificationsRead(claimsOf(c).User); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// GetNotificationPreferences handles the "/users/restricted/me/notifications/preferences" route.
+func GetNotificationPreferences(c echo.Context) error {
+	prefs, err := model.ReadNotificationPreferences(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PreferencesResponse{Response: ok(status), Preferences: prefs})
+}
+
+// UpdateNotificationPreferences handles the "/users/restricted/me/notifications/preferences" route.
+func UpdateNotificationPreferences(c echo.Context) error {
+	claims := claimsOf(c)
+	r := preferencesRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if err := model.UpdateNotificationPreferences(claims.User, r.Preferences); err != nil {
+		return err
+	}
+
+	prefs, err := model.ReadNotificationPreferences(claims.User)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, PreferencesResponse{Response: ok(status), Preferences: prefs})
+}
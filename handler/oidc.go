@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/oidc"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Providers are the OpenID Connect providers Users can log in with, by name
+var Providers = map[string]*oidc.Provider{}
+
+// oidcCookie carries a login from "/users/oidc/:provider/login" to its callback
+const oidcCookie = "yap_oidc"
+
+// oidcLife is how long a User has to log in at their provider
+const oidcLife = 10 * time.Minute
+
+// oidcState is what a login remembers in oidcCookie.
+// The state itself is the token ID. Link names the User linking the
+// provider account, when they started from "/users/restricted/me/oidc".
+type oidcState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Link     string `json:"link,omitempty"`
+	jwt.StandardClaims
+}
+
+// LinkResponse is a response containing the address to link a provider at
+type LinkResponse struct {
+	Response
+	URL string `json:"data"`
+}
+
+// ProvidersResponse is a response containing the names of login providers
+type ProvidersResponse struct {
+	Response
+	Names []string `json:"data"`
+}
+
+// providerOf returns the Provider named in the route
+func providerOf(c echo.Context) (*oidc.Provider, error) {
+	p, found := Providers[c.Param("provider")]
+	if !found {
+		return nil, errs.New(http.StatusNotFound, errs.CodeNotFound, "There is no such login provider.")
+	}
+	return p, nil
+}
+
+// GetProviders handles the "/users/oidc" route.
+func GetProviders(c echo.Context) error {
+	names := []string{}
+	for name := range Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := http.StatusOK
+	return c.JSON(status, ProvidersResponse{Response: ok(status), Names: names})
+}
+
+// LoginProvider handles the "/users/oidc/:provider/login" route.
+// It sends the User to log in at the provider.
+func LoginProvider(c echo.Context) error {
+	p, err := providerOf(c)
+	if err != nil {
+		return err
+	}
+
+	u, err := startOIDC(c, p, oidcState{})
+	if err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, u)
+}
+
+// LinkProvider handles the "/users/restricted/me/oidc/:provider/link" route.
+// It returns where the User should go to link their account at the
+// provider, since a redirect would not carry their token.
+func LinkProvider(c echo.Context) error {
+	p, err := providerOf(c)
+	if err != nil {
+		return err
+	}
+
+	u, err := startOIDC(c, p, oidcState{Link: claimsOf(c).User.String()})
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, LinkResponse{Response: ok(status), URL: u})
+}
+
+// startOIDC begins a login at p with PKCE, remembering s with a fresh
+// state, nonce and verifier in a signed cookie, and returns the address to
+// send the User to
+func startOIDC(c echo.Context, p *oidc.Provider, s oidcState) (string, error) {
+	s.Provider, s.Audience = p.Name, audOIDC
+	var err error
+	for _, v := range []*string{&s.Id, &s.Nonce, &s.Verifier} {
+		if *v, err = oidc.Random(); err != nil {
+			return "", errs.Internal(err)
+		}
+	}
+
+	expires := time.Now().Add(oidcLife)
+	s.ExpiresAt = expires.Unix()
+	value, err := signFor(&s)
+	if err != nil {
+		return "", errs.Internal(err)
+	}
+
+	u, err := p.AuthURL(s.Id, s.Nonce, s.Verifier)
+	if err != nil {
+		return "", errs.Wrap(err, http.StatusBadGateway, errs.CodeInternal, "The login provider is unavailable.")
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     oidcCookie,
+		Value:    value,
+		Path:     "/users/oidc",
+		Expires:  expires,
+		Secure:   c.Scheme() == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return u, nil
+}
+
+// readOIDCState checks the login cookie of c against the callback of p
+func readOIDCState(c echo.Context, p *oidc.Provider) (oidcState, error) {
+	s := oidcState{}
+	fail := errs.New(http.StatusUnauthorized, errs.CodeUnauthorized, "The login expired or did not start here. Try again.")
+
+	cookie, err := c.Cookie(oidcCookie)
+	if err != nil {
+		return s, fail
+	}
+	if err := parseFor(cookie.Value, audOIDC, &s); err != nil || s.Provider != p.Name || s.Id == "" || s.Id != c.QueryParam("state") {
+		return s, fail
+	}
+	return s, nil
+}
+
+// ProviderCallback handles the "/users/oidc/:provider/callback" route.
+// It logs in the User the provider vouches for, like AuthUser would, or
+// finishes linking the provider account to the User who asked to.
+func ProviderCallback(c echo.Context) error {
+	p, err := providerOf(c)
+	if err != nil {
+		return err
+	}
+
+	s, err := readOIDCState(c, p)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(&http.Cookie{Name: oidcCookie, Path: "/users/oidc", MaxAge: -1, HttpOnly: true})
+
+	if reason := c.QueryParam("error"); reason != "" {
+		return errs.New(http.StatusUnauthorized, errs.CodeUnauthorized, "The login provider refused: "+reason)
+	}
+
+	id, err := p.Exchange(c.QueryParam("code"), s.Verifier, s.Nonce)
+	if err != nil {
+		return errs.Wrap(err, http.StatusUnauthorized, errs.CodeUnauthorized, "The login provider did not confirm your login.")
+	}
+
+	by := model.Actor{IP: c.RealIP(), Agent: c.Request().UserAgent()}
+	if s.Link != "" {
+		by.User = uuid.FromStringOrNil(s.Link)
+		user, err := model.LinkIdentity(by.User, p.Name, id.Subject, id.Email, by)
+		if err != nil {
+			return err
+		}
+
+		user.Pass = ""
+		status := http.StatusAccepted
+		return c.JSON(status, UserResponse{Response: ok(status), User: user})
+	}
+
+	user, err := model.LoginIdentity(p.Name, id.Subject, id.Email, id.Name, id.EmailVerified, by)
+	if err != nil {
+		return err
+	}
+
+	if user.TwoFactor {
+		return sendChallenge(c, user)
+	}
+
+	user.Pass = ""
+	authString, err := createAuthString(c, user, false)
+	if err != nil {
+		return err
+	}
+
+	user.Auth = authString
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
+}
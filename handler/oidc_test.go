@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/l3njo/yap/oidc"
+	"github.com/labstack/echo/v4"
+)
+
+func TestOIDCState(t *testing.T) {
+	useTestKeys(t)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	local, err := oidc.NewLocal(srv.URL+"/oidc/local", "yap")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	mux.Handle("/oidc/local/", local)
+	p := local.Provider("local", "https://yap.example")
+	Providers = map[string]*oidc.Provider{"local": p}
+	defer func() { Providers = map[string]*oidc.Provider{} }()
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users/oidc/local/login", nil), rec)
+	c.SetParamNames("provider")
+	c.SetParamValues("local")
+	if err := LoginProvider(c); err != nil {
+		t.Fatalf("LoginProvider() error = %v", err)
+	}
+	if rec.Code != http.StatusFound {
+		t.Fatalf("LoginProvider() status = %v, want %v", rec.Code, http.StatusFound)
+	}
+
+	loc, err := url.Parse(rec.Header().Get(echo.HeaderLocation))
+	if err != nil {
+		t.Fatalf("LoginProvider() Location error = %v", err)
+	}
+	if loc.Query().Get("code_challenge_method") != "S256" || loc.Query().Get("redirect_uri") != p.RedirectURL {
+		t.Errorf("LoginProvider() redirected to %v", loc)
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	tests := []struct {
+		name    string
+		state   string
+		cookie  *http.Cookie
+		wantErr bool
+	}{
+		{name: "Matching State Test", state: loc.Query().Get("state"), cookie: cookie},
+		{name: "Wrong State Test", state: "forged", cookie: cookie, wantErr: true},
+		{name: "No Cookie Test", state: loc.Query().Get("state"), wantErr: true},
+		{name: "Forged Cookie Test", state: loc.Query().Get("state"), cookie: &http.Cookie{Name: oidcCookie, Value: cookie.Value + "x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/oidc/local/callback?state="+url.QueryEscape(tt.state), nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			s, err := readOIDCState(e.NewContext(req, httptest.NewRecorder()), p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readOIDCState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && oidc.Challenge(s.Verifier) != loc.Query().Get("code_challenge") {
+				t.Error("readOIDCState() verifier does not match the challenge sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/openapi"
+	"github.com/labstack/echo/v4"
+)
+
+// routeDoc describes a route for the OpenAPI document
+type routeDoc struct {
+	Summary string
+	Tag     string
+	Auth    bool
+	Body    interface{}
+	Status  int
+	Data    interface{}
+	// Stream marks routes that send Data as Server-Sent Events
+	Stream bool
+	// Download is the media type of routes that send a file instead of JSON
+	Download string
+}
+
+// routeDocs documents every route registered in Routes, keyed by "METHOD path".
+// TestRoutesDocumented fails when a route is missing here.
+var routeDocs = map[string]routeDoc{
+	"GET /":                      {Summary: "Greet API clients", Tag: "meta"},
+	"GET /openapi.json":          {Summary: "Get this OpenAPI document", Tag: "meta"},
+	"GET /docs":                  {Summary: "Browse the API reference", Tag: "meta"},
+	"GET /docs/redoc.js":         {Summary: "Get the script that renders the API reference", Tag: "meta", Download: "text/javascript"},
+	"GET /.well-known/jwks.json": {Summary: "Get the public keys that verify auth tokens", Tag: "meta"},
+
+	"GET /users":                                         {Summary: "List users' public profiles", Tag: "users", Data: []model.Profile{}},
+	"GET /users/:id":                                     {Summary: "Get a user's public profile", Tag: "users", Data: model.Profile{}},
+	"GET /users/by-handle/:handle":                       {Summary: "Get a user's public profile by handle", Tag: "users", Data: model.Profile{}},
+	"POST /users/join":                                   {Summary: "Register a user", Tag: "users", Body: joinRequest{}, Status: http.StatusCreated, Data: model.User{}},
+	"POST /users/auth":                                   {Summary: "Log in, or get a two-factor challenge", Tag: "users", Body: authRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"GET /users/:id/posts/articles":                      {Summary: "List a user's public articles", Tag: "users", Data: []model.Article{}},
+	"GET /users/:id/posts/galleries":                     {Summary: "List a user's public galleries", Tag: "users", Data: []model.Gallery{}},
+	"GET /users/:id/posts/flickers":                      {Summary: "List a user's public flickers", Tag: "users", Data: []model.Flicker{}},
+	"GET /users/:id/reactions":                           {Summary: "List a user's reactions", Tag: "users", Data: []model.Reaction{}},
+	"PUT /users/restricted/me/update":                    {Summary: "Update your profile", Tag: "users", Auth: true, Body: userRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"PUT /users/restricted/me/change":                    {Summary: "Change your password", Tag: "users", Auth: true, Body: passRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"GET /users/:id/followers":                           {Summary: "List a user's followers", Tag: "follows", Data: []model.Profile{}},
+	"GET /users/:id/following":                           {Summary: "List the users a user follows", Tag: "follows", Data: []model.Profile{}},
+	"GET /users/restricted/me/feed":                      {Summary: "Page through posts from everything you follow", Tag: "follows", Auth: true, Data: []model.Post{}},
+	"GET /users/restricted/me/bookmarks":                 {Summary: "Get your bookmarks", Tag: "lists", Auth: true, Data: model.ReadingList{}},
+	"POST /users/restricted/me/bookmarks/create":         {Summary: "Bookmark a post", Tag: "lists", Auth: true, Body: listItemRequest{}, Status: http.StatusCreated, Data: model.ReadingItem{}},
+	"GET /users/restricted/me/follows":                   {Summary: "List what you follow", Tag: "follows", Auth: true, Data: []model.Follow{}},
+	"POST /users/restricted/me/follows/create":           {Summary: "Follow a section or marker", Tag: "follows", Auth: true, Body: followRequest{}, Status: http.StatusCreated, Data: model.Follow{}},
+	"DELETE /users/restricted/me/follows/:id/delete":     {Summary: "Stop following something", Tag: "follows", Auth: true, Status: http.StatusAccepted},
+	"PUT /users/restricted/:id/follow":                   {Summary: "Follow a user", Tag: "follows", Auth: true, Data: model.Follow{}},
+	"PUT /users/restricted/:id/unfollow":                 {Summary: "Unfollow a user", Tag: "follows", Auth: true, Status: http.StatusAccepted},
+	"PUT /users/restricted/:id/assign":                   {Summary: "Assign a user's role", Tag: "users", Auth: true, Body: assignRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"GET /users/restricted/me/events":                    {Summary: "Stream your notifications", Tag: "streams", Auth: true, Data: event.Event{}, Stream: true},
+	"GET /users/restricted/me/notifications":             {Summary: "List your notifications", Tag: "notifications", Auth: true, Data: []model.Notification{}},
+	"PUT /users/restricted/me/notifications/read":        {Summary: "Mark all notifications read", Tag: "notifications", Auth: true, Status: http.StatusAccepted},
+	"PUT /users/restricted/me/notifications/:id/read":    {Summary: "Mark a notification read", Tag: "notifications", Auth: true, Status: http.StatusAccepted, Data: model.Notification{}},
+	"GET /users/restricted/me/notifications/preferences": {Summary: "Get your notification preferences", Tag: "notifications", Auth: true, Data: map[model.NotificationKind]bool{}},
+	"PUT /users/restricted/me/notifications/preferences": {Summary: "Update your notification preferences", Tag: "notifications", Auth: true, Body: preferencesRequest{}, Status: http.StatusAccepted, Data: map[model.NotificationKind]bool{}},
+	"DELETE /users/restricted/:id/delete":                {Summary: "Delete a user", Tag: "users", Auth: true, Status: http.StatusAccepted},
+	"GET /users/restricted/me/export":                    {Summary: "Download all your data as a ZIP archive", Tag: "privacy", Auth: true, Download: "application/zip"},
+	"DELETE /users/restricted/:id/erase":                 {Summary: "Erase a user's personal data for good", Tag: "privacy", Auth: true, Status: http.StatusAccepted},
+
+	"GET /users/oidc":                                 {Summary: "List the providers you can log in with", Tag: "users", Data: []string{}},
+	"GET /users/oidc/:provider/login":                 {Summary: "Log in with a provider", Tag: "users", Status: http.StatusFound},
+	"GET /users/oidc/:provider/callback":              {Summary: "Finish logging in with, or linking, a provider", Tag: "users", Status: http.StatusAccepted, Data: model.User{}},
+	"POST /users/restricted/me/oidc/:provider/link":   {Summary: "Start linking a provider account to yours", Tag: "users", Auth: true, Status: http.StatusAccepted, Data: ""},
+	"POST /users/auth/2fa":                            {Summary: "Answer a login challenge with a TOTP or recovery code", Tag: "2fa", Body: challengeRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"POST /users/restricted/me/2fa/enroll":            {Summary: "Start enrolling an authenticator app", Tag: "2fa", Auth: true, Status: http.StatusCreated, Data: Enrollment{}},
+	"PUT /users/restricted/me/2fa/enable":             {Summary: "Confirm a code to turn on two-factor authentication", Tag: "2fa", Auth: true, Body: codeRequest{}, Status: http.StatusAccepted, Data: []string{}},
+	"PUT /users/restricted/me/2fa/disable":            {Summary: "Turn off two-factor authentication", Tag: "2fa", Auth: true, Body: codeRequest{}, Status: http.StatusAccepted, Data: model.User{}},
+	"GET /users/restricted/me/tokens":                 {Summary: "List your personal access tokens", Tag: "tokens", Auth: true, Data: []model.AccessToken{}},
+	"POST /users/restricted/me/tokens/create":         {Summary: "Create a personal access token", Tag: "tokens", Auth: true, Body: tokenRequest{}, Status: http.StatusCreated, Data: model.AccessToken{}},
+	"DELETE /users/restricted/me/tokens/:id/delete":   {Summary: "Revoke a personal access token", Tag: "tokens", Auth: true, Status: http.StatusAccepted},
+	"POST /users/restricted/me/streams/ticket":        {Summary: "Get a ticket to open a stream from a browser", Tag: "streams", Auth: true, Status: http.StatusCreated, Data: StreamTicket{}},
+	"GET /users/restricted/me/sessions":               {Summary: "List where you are logged in", Tag: "sessions", Auth: true, Data: []model.Session{}},
+	"DELETE /users/restricted/me/sessions/delete":     {Summary: "Log out everywhere else", Tag: "sessions", Auth: true, Status: http.StatusAccepted, Data: int64(0)},
+	"DELETE /users/restricted/me/sessions/:id/delete": {Summary: "Revoke one of your sessions", Tag: "sessions", Auth: true, Status: http.StatusAccepted},
+	"GET /users/restricted/:id/sessions":              {Summary: "List where a user is logged in", Tag: "sessions", Auth: true, Data: []model.Session{}},
+	"DELETE /users/restricted/:id/sessions/delete":    {Summary: "Log a user out everywhere", Tag: "sessions", Auth: true, Status: http.StatusAccepted, Data: int64(0)},
+	"POST /users/restricted/me/2fa/recovery":          {Summary: "Replace your recovery codes", Tag: "2fa", Auth: true, Body: codeRequest{}, Status: http.StatusCreated, Data: []string{}},
+
+	"GET /posts/:id/authors":                 {Summary: "List a post's authors and invitations", Tag: "posts", Auth: true, Data: []model.PostAuthor{}},
+	"POST /posts/:id/authors/invite":         {Summary: "Invite a user to work on a post", Tag: "posts", Auth: true, Body: authorRequest{}, Status: http.StatusCreated, Data: model.PostAuthor{}},
+	"PUT /posts/:id/authors/accept":          {Summary: "Accept your invitation to a post", Tag: "posts", Auth: true, Status: http.StatusAccepted, Data: model.PostAuthor{}},
+	"DELETE /posts/:id/authors/:user/delete": {Summary: "Remove an author or decline an invitation", Tag: "posts", Auth: true, Status: http.StatusAccepted},
+	"GET /posts/:id/related":                 {Summary: "List released posts related to a post", Tag: "posts", Data: []model.Post{}},
+	"DELETE /posts/:id/delete":               {Summary: "Delete a post", Tag: "posts", Auth: true, Status: http.StatusAccepted},
+	"PUT /posts/:id/submit":                  {Summary: "Submit a draft for review", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/approve":                 {Summary: "Approve a post for publishing", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/request-changes":         {Summary: "Send a post back to draft with comments", Tag: "workflow", Auth: true, Body: changesRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/publish":                 {Summary: "Publish an approved post", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/retract":                 {Summary: "Retract a post", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/archive":                 {Summary: "Archive a post", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /posts/:id/unarchive":               {Summary: "Return an archived post to draft", Tag: "workflow", Auth: true, Body: reviewRequest{}, Status: http.StatusAccepted, Data: new(model.Post)},
+	"GET /posts/:id/history":                 {Summary: "List a post's workflow history", Tag: "workflow", Auth: true, Data: []model.PostTransition{}},
+
+	"GET /admin/audit":        {Summary: "Page through the audit log", Tag: "admin", Auth: true, Data: []model.AuditEntry{}},
+	"GET /admin/audit/export": {Summary: "Export the audit log as CSV", Tag: "admin", Auth: true, Download: "text/csv"},
+	"GET /admin/2fa":          {Summary: "List the roles that must use two-factor authentication", Tag: "admin", Auth: true, Data: []model.UserRole{}},
+	"PUT /admin/2fa/update":   {Summary: "Change the roles that must use two-factor authentication", Tag: "admin", Auth: true, Body: twoFactorPolicyRequest{}, Status: http.StatusAccepted, Data: []model.UserRole{}},
+
+	"GET /trash/posts":                 {Summary: "List deleted posts", Tag: "trash", Auth: true, Data: []model.Post{}},
+	"GET /trash/reactions":             {Summary: "List deleted reactions", Tag: "trash", Auth: true, Data: []model.Reaction{}},
+	"GET /trash/users":                 {Summary: "List deleted users", Tag: "trash", Auth: true, Data: []model.User{}},
+	"PUT /trash/posts/:id/restore":     {Summary: "Restore a deleted post", Tag: "trash", Auth: true, Status: http.StatusAccepted, Data: new(model.Post)},
+	"PUT /trash/reactions/:id/restore": {Summary: "Restore a deleted reaction", Tag: "trash", Auth: true, Status: http.StatusAccepted, Data: model.Reaction{}},
+	"PUT /trash/users/:id/restore":     {Summary: "Restore a deleted user with their posts and reactions", Tag: "trash", Auth: true, Status: http.StatusAccepted, Data: model.User{}},
+
+	"GET /posts/:id/reactions":                                {Summary: "List a post's reactions", Tag: "reactions", Data: []model.Reaction{}},
+	"GET /posts/:id/reactions/stream":                         {Summary: "Stream a post's reactions", Tag: "streams", Auth: true, Data: event.Event{}, Stream: true},
+	"GET /posts/:id/reactions/:reaction":                      {Summary: "Get a reaction", Tag: "reactions", Data: model.Reaction{}},
+	"POST /posts/:id/reactions/restricted/create":             {Summary: "React to a post", Tag: "reactions", Auth: true, Body: reactionRequest{}, Status: http.StatusCreated, Data: model.Reaction{}},
+	"PUT /posts/:id/reactions/restricted/:reaction/update":    {Summary: "Edit a comment", Tag: "reactions", Auth: true, Body: reactionUpdateRequest{}, Status: http.StatusAccepted, Data: model.Reaction{}},
+	"DELETE /posts/:id/reactions/restricted/:reaction/delete": {Summary: "Delete a reaction", Tag: "reactions", Auth: true, Status: http.StatusAccepted},
+
+	"GET /posts/articles/public":               {Summary: "List public articles", Tag: "articles", Data: []model.Article{}},
+	"GET /posts/articles/public/:id":           {Summary: "Get a public article", Tag: "articles", Data: model.Article{}},
+	"GET /posts/articles/public/by-slug/:slug": {Summary: "Get a public article by slug", Tag: "articles", Data: model.Article{}},
+	"GET /posts/articles":                      {Summary: "List articles", Tag: "articles", Auth: true, Data: []model.Article{}},
+	"GET /posts/articles/:id":                  {Summary: "Get an article", Tag: "articles", Auth: true, Data: model.Article{}},
+	"POST /posts/articles/create":              {Summary: "Create an article", Tag: "articles", Auth: true, Body: articleRequest{}, Status: http.StatusCreated, Data: model.Article{}},
+	"PUT /posts/articles/:id/update":           {Summary: "Update an article", Tag: "articles", Auth: true, Body: articleUpdateRequest{}, Status: http.StatusAccepted, Data: model.Article{}},
+	"PUT /posts/articles/:id/transfer":         {Summary: "Transfer an article", Tag: "articles", Auth: true, Body: transferRequest{}, Status: http.StatusAccepted, Data: model.Article{}},
+
+	"GET /posts/galleries/public":               {Summary: "List public galleries", Tag: "galleries", Data: []model.Gallery{}},
+	"GET /posts/galleries/public/:id":           {Summary: "Get a public gallery", Tag: "galleries", Data: model.Gallery{}},
+	"GET /posts/galleries/public/by-slug/:slug": {Summary: "Get a public gallery by slug", Tag: "galleries", Data: model.Gallery{}},
+	"GET /posts/galleries":                      {Summary: "List galleries", Tag: "galleries", Auth: true, Data: []model.Gallery{}},
+	"GET /posts/galleries/:id":                  {Summary: "Get a gallery", Tag: "galleries", Auth: true, Data: model.Gallery{}},
+	"POST /posts/galleries/create":              {Summary: "Create a gallery", Tag: "galleries", Auth: true, Body: galleryRequest{}, Status: http.StatusCreated, Data: model.Gallery{}},
+	"PUT /posts/galleries/:id/update":           {Summary: "Update a gallery", Tag: "galleries", Auth: true, Body: galleryUpdateRequest{}, Status: http.StatusAccepted, Data: model.Gallery{}},
+	"PUT /posts/galleries/:id/transfer":         {Summary: "Transfer a gallery", Tag: "galleries", Auth: true, Body: transferRequest{}, Status: http.StatusAccepted, Data: model.Gallery{}},
+
+	"GET /posts/flickers/public":               {Summary: "List public flickers", Tag: "flickers", Data: []model.Flicker{}},
+	"GET /posts/flickers/public/:id":           {Summary: "Get a public flicker", Tag: "flickers", Data: model.Flicker{}},
+	"GET /posts/flickers/public/by-slug/:slug": {Summary: "Get a public flicker by slug", Tag: "flickers", Data: model.Flicker{}},
+	"GET /posts/flickers":                      {Summary: "List flickers", Tag: "flickers", Auth: true, Data: []model.Flicker{}},
+	"GET /posts/flickers/:id":                  {Summary: "Get a flicker", Tag: "flickers", Auth: true, Data: model.Flicker{}},
+	"POST /posts/flickers/create":              {Summary: "Create a flicker", Tag: "flickers", Auth: true, Body: flickerRequest{}, Status: http.StatusCreated, Data: model.Flicker{}},
+	"PUT /posts/flickers/:id/update":           {Summary: "Update a flicker", Tag: "flickers", Auth: true, Body: flickerUpdateRequest{}, Status: http.StatusAccepted, Data: model.Flicker{}},
+	"PUT /posts/flickers/:id/transfer":         {Summary: "Transfer a flicker", Tag: "flickers", Auth: true, Body: transferRequest{}, Status: http.StatusAccepted, Data: model.Flicker{}},
+
+	"GET /markers":                                   {Summary: "List markers with their post counts", Tag: "markers", Data: []model.MarkerCount{}},
+	"GET /markers/suggest":                           {Summary: "Complete a marker", Tag: "markers", Data: []model.MarkerCount{}},
+	"GET /markers/:name/posts":                       {Summary: "List released posts with a marker", Tag: "markers", Data: []model.Post{}},
+	"PUT /markers/restricted/:name/rename":           {Summary: "Rename a marker on every post", Tag: "markers", Auth: true, Body: markerRenameRequest{}, Status: http.StatusAccepted},
+	"PUT /markers/restricted/merge":                  {Summary: "Merge markers on every post", Tag: "markers", Auth: true, Body: markerMergeRequest{}, Status: http.StatusAccepted},
+	"GET /series":                                    {Summary: "List series", Tag: "series", Data: []model.Series{}},
+	"GET /series/:id":                                {Summary: "Get a series and its released posts", Tag: "series", Data: model.Series{}},
+	"POST /series/create":                            {Summary: "Create a series", Tag: "series", Auth: true, Body: seriesRequest{}, Status: http.StatusCreated, Data: model.Series{}},
+	"PUT /series/:id/update":                         {Summary: "Update a series", Tag: "series", Auth: true, Body: seriesRequest{}, Status: http.StatusAccepted, Data: model.Series{}},
+	"DELETE /series/:id/delete":                      {Summary: "Delete a series", Tag: "series", Auth: true, Status: http.StatusAccepted},
+	"POST /series/:id/posts/create":                  {Summary: "Add a post to a series", Tag: "series", Auth: true, Body: seriesPostRequest{}, Status: http.StatusCreated, Data: model.Series{}},
+	"DELETE /series/:id/posts/:post/delete":          {Summary: "Remove a post from a series", Tag: "series", Auth: true, Status: http.StatusAccepted},
+	"PUT /series/:id/posts/order":                    {Summary: "Reorder the posts in a series", Tag: "series", Auth: true, Body: seriesOrderRequest{}, Status: http.StatusAccepted, Data: model.Series{}},
+	"GET /lists/shared/:token":                       {Summary: "Get a shared reading list", Tag: "lists", Data: model.ReadingList{}},
+	"GET /lists":                                     {Summary: "List your reading lists", Tag: "lists", Auth: true, Data: []model.ReadingList{}},
+	"POST /lists/create":                             {Summary: "Create a reading list", Tag: "lists", Auth: true, Body: listRequest{}, Status: http.StatusCreated, Data: model.ReadingList{}},
+	"GET /lists/:id":                                 {Summary: "Get one of your reading lists", Tag: "lists", Auth: true, Data: model.ReadingList{}},
+	"PUT /lists/:id/update":                          {Summary: "Rename or share a reading list", Tag: "lists", Auth: true, Body: listRequest{}, Status: http.StatusAccepted, Data: model.ReadingList{}},
+	"DELETE /lists/:id/delete":                       {Summary: "Delete a reading list", Tag: "lists", Auth: true, Status: http.StatusAccepted},
+	"POST /lists/:id/items/create":                   {Summary: "Save a post to a reading list", Tag: "lists", Auth: true, Body: listItemRequest{}, Status: http.StatusCreated, Data: model.ReadingItem{}},
+	"DELETE /lists/:id/items/:item/delete":           {Summary: "Remove a post from a reading list", Tag: "lists", Auth: true, Status: http.StatusAccepted},
+	"PUT /lists/:id/items/order":                     {Summary: "Reorder a reading list", Tag: "lists", Auth: true, Body: listOrderRequest{}, Status: http.StatusAccepted, Data: model.ReadingList{}},
+	"GET /hooks":                                     {Summary: "List webhooks", Tag: "hooks", Auth: true, Data: []model.Webhook{}},
+	"POST /hooks/create":                             {Summary: "Create a webhook", Tag: "hooks", Auth: true, Body: webhookRequest{}, Status: http.StatusCreated, Data: model.Webhook{}},
+	"GET /hooks/:id":                                 {Summary: "Get a webhook", Tag: "hooks", Auth: true, Data: model.Webhook{}},
+	"PUT /hooks/:id/update":                          {Summary: "Update a webhook", Tag: "hooks", Auth: true, Body: webhookRequest{}, Status: http.StatusAccepted, Data: model.Webhook{}},
+	"DELETE /hooks/:id/delete":                       {Summary: "Delete a webhook", Tag: "hooks", Auth: true, Status: http.StatusAccepted},
+	"GET /hooks/:id/deliveries":                      {Summary: "List a webhook's deliveries", Tag: "hooks", Auth: true, Data: []model.WebhookDelivery{}},
+	"POST /hooks/:id/deliveries/:delivery/redeliver": {Summary: "Redeliver a webhook delivery", Tag: "hooks", Auth: true, Status: http.StatusAccepted, Data: []model.WebhookDelivery{}},
+}
+
+// notFoundName is the name echo gives the catch-all routes added by Group.Use
+var notFoundName = runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+
+// documentedRoutes returns the routes of e that belong in the OpenAPI document
+func documentedRoutes(e *echo.Echo) []*echo.Route {
+	routes := []*echo.Route{}
+	for _, r := range e.Routes() {
+		if r.Name == notFoundName || r.Method == echo.RouteNotFound {
+			continue
+		}
+		routes = append(routes, r)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Path+routes[i].Method < routes[j].Path+routes[j].Method
+	})
+	return routes
+}
+
+// buildOpenAPI generates the OpenAPI document for the routes of e
+func buildOpenAPI(e *echo.Echo) *openapi.Document {
+	doc := openapi.New("Yap API", "1.0.0")
+	doc.Info.Description = "Yet Another Platform. Yet another blog/forum backend."
+	problem := doc.Schema(errs.Problem{})
+
+	for _, r := range documentedRoutes(e) {
+		rd, ok := routeDocs[r.Method+" "+r.Path]
+		if !ok {
+			continue
+		}
+
+		status := rd.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		op := &openapi.Operation{
+			OperationID: r.Name[strings.LastIndex(r.Name, ".")+1:],
+			Summary:     rd.Summary,
+			Tags:        []string{rd.Tag},
+			Responses: map[string]openapi.Response{
+				strconv.Itoa(status): {Description: http.StatusText(status), Content: envelope(doc, rd.Data)},
+				"default": {
+					Description: "Problem details",
+					Content:     map[string]openapi.MediaType{"application/problem+json": {Schema: problem}},
+				},
+			},
+		}
+
+		if rd.Stream {
+			op.Responses[strconv.Itoa(status)] = openapi.Response{
+				Description: "Server-Sent Events, or JSON frames after a WebSocket upgrade",
+				Content:     map[string]openapi.MediaType{"text/event-stream": {Schema: doc.Schema(rd.Data)}},
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        "ticket",
+				In:          "query",
+				Description: "A stream ticket, for clients that cannot send an Authorization header",
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+		}
+
+		if rd.Download != "" {
+			op.Responses[strconv.Itoa(status)] = openapi.Response{
+				Description: "A file to save",
+				Content:     map[string]openapi.MediaType{rd.Download: {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+			}
+		}
+
+		if rd.Tag == "meta" {
+			op.Responses[strconv.Itoa(status)] = openapi.Response{Description: http.StatusText(status)}
+		}
+
+		if rd.Body != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(rd.Body))}
+		}
+
+		if rd.Auth {
+			op.Security = []map[string][]string{{"bearer": {}}}
+		}
+
+		if scope := routeScopes[r.Method+" "+r.Path]; scope != "" {
+			op.Security = append(op.Security, map[string][]string{"token": {}})
+			op.Description = "Personal access tokens need the " + string(scope) + " scope."
+		}
+
+		doc.Add(r.Method, r.Path, op)
+	}
+
+	return doc
+}
+
+// envelope wraps the Schema of data in the Response fields every endpoint returns
+func envelope(doc *openapi.Document, data interface{}) map[string]openapi.MediaType {
+	s := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+		"status":  {Type: "boolean"},
+		"message": {Type: "string"},
+	}}
+	if data != nil {
+		s.Properties["data"] = doc.Schema(data)
+	}
+	return openapi.JSON(s)
+}
+
+// GetOpenAPI handles the "/openapi.json" route.
+func GetOpenAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, buildOpenAPI(c.Echo()))
+}
+
+// redocCDN is the pinned Redoc bundle /docs loads when no RedocBundle is served
+const redocCDN = "https://cdn.jsdelivr.net/npm/redoc@2.1.5/bundles/redoc.standalone.js"
+
+// RedocBundle is the path of a local copy of the Redoc bundle. When set,
+// /docs loads it from this server instead of redocCDN.
+var RedocBundle string
+
+// docsPage renders the OpenAPI document with the Redoc bundle at %s
+const docsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Yap API</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="/openapi.json"></redoc>
+    <script src="%s"></script>
+  </body>
+</html>
+`
+
+// GetDocs handles the "/docs" route.
+func GetDocs(c echo.Context) error {
+	script := redocCDN
+	if RedocBundle != "" {
+		script = "/docs/redoc.js"
+	}
+	return c.HTML(http.StatusOK, fmt.Sprintf(docsPage, script))
+}
+
+// GetDocsScript handles the "/docs/redoc.js" route.
+// It serves RedocBundle, when there is one.
+func GetDocsScript(c echo.Context) error {
+	if RedocBundle == "" {
+		return errs.NotFound(nil)
+	}
+	return c.File(RedocBundle)
+}
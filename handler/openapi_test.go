@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/openapi"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func newTestEcho() *echo.Echo {
+	e := echo.New()
+	Routes(e, middleware.JWTConfig{SigningKey: []byte("test")})
+	return e
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	registered := map[string]bool{}
+	for _, r := range documentedRoutes(newTestEcho()) {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+		if _, ok := routeDocs[key]; !ok {
+			t.Errorf("route %q has no OpenAPI entry in routeDocs", key)
+		}
+	}
+
+	for key := range routeDocs {
+		if !registered[key] {
+			t.Errorf("routeDocs entry %q has no registered route", key)
+		}
+	}
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	e := newTestEcho()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetOpenAPI() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	doc := openapi.Document{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	join := (*doc.Paths["/users/join"])["post"]
+	if join == nil || join.RequestBody == nil {
+		t.Fatalf("GetOpenAPI() missing request body for POST /users/join")
+	}
+
+	article := (*doc.Paths["/posts/articles/{id}/update"])["put"]
+	if article == nil || len(article.Parameters) != 1 || article.Parameters[0].Name != "id" {
+		t.Errorf("GetOpenAPI() parameters = %v, want id path parameter", article)
+	}
+
+	list := (*doc.Paths["/lists/create"])["post"]
+	if list == nil || !strings.Contains(list.Description, string(model.ScopeListsWrite)) {
+		t.Errorf("GetOpenAPI() POST /lists/create = %+v, want the %s scope", list, model.ScopeListsWrite)
+	}
+
+	body := doc.Components.Schemas["HandlerJoinRequest"]
+	if body == nil || len(body.Required) != 3 || body.Properties["mail"].Format != "email" {
+		t.Errorf("GetOpenAPI() joinRequest schema = %+v", body)
+	}
+}
+
+func TestGetDocs(t *testing.T) {
+	e := newTestEcho()
+	e.HTTPErrorHandler = ErrorHandler
+	defer func(bundle string) { RedocBundle = bundle }(RedocBundle)
+
+	for bundle, script := range map[string]string{"": redocCDN, "redoc.js": "/docs/redoc.js"} {
+		RedocBundle = bundle
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		if !strings.Contains(rec.Body.String(), `<script src="`+script+`">`) {
+			t.Errorf("GetDocs() with bundle %q = %s, want script %s", bundle, rec.Body, script)
+		}
+	}
+
+	RedocBundle = ""
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/redoc.js", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GetDocsScript() without a bundle status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+}
@@ -2,145 +2,92 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
-	uuid "github.com/satori/go.uuid"
+	"github.com/l3njo/yap/related"
 
 	"github.com/labstack/echo/v4"
 )
 
+// relatedLimit is how many related posts are returned by default
+const relatedLimit = 5
+
 // PostResponse is a response containing one Post
 type PostResponse struct {
 	Response
 	model.Post `json:"data"`
 }
 
-// PublishPost handles the "/posts/:id/publish" route.
-func PublishPost(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	id := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(id, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	if !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	post, status, err := model.GetPost(id)
+// DeletePost handles the "/posts/:id/delete" route.
+func DeletePost(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	status, err = post.Publish()
+	post, err := model.GetPost(id)
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), post
-	return c.JSON(status, resp)
-}
-
-// RetractPost handles the "/posts/:id/retract" route.
-func RetractPost(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	id := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(id, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	if !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if !canEditPost(post.Meta(), claims) {
+		return errs.Forbidden()
 	}
 
-	post, status, err := model.GetPost(id)
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := post.Delete(); err != nil {
+		return err
 	}
 
-	status, err = post.Retract()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
+	event.Publish(event.New(event.PostDeleted, claims.User, post))
 
-	resp.Status, resp.Message, resp.Post = true, http.StatusText(status), post
-	return c.JSON(status, resp)
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status)})
 }
 
-// DeletePost handles the "/posts/:id/delete" route.
-func DeletePost(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := PostResponse{}, 0
-	id := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(id, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+// canEditPost reports whether the holder of claims may change a Post.
+// Drafts need draftOps and released posts need postOps.
+func canEditPost(post *model.PostBase, claims *JwtCustomClaims) bool {
+	if post.Release {
+		return RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)
 	}
+	return RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil)
+}
 
-	post, status, err := model.GetPost(id)
+// GetRelatedPosts handles the "/posts/:id/related" route.
+// It returns up to "limit" released posts, five by default.
+func GetRelatedPosts(c echo.Context) error {
+	id, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	if status = checkDeletePermissions(post, claims.Role); status != http.StatusOK {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	limit := relatedLimit
+	if s := c.QueryParam("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > related.MaxResults {
+			return errs.Invalid(errs.Field{Name: "limit", Code: errs.FieldInvalid, Message: "Must be between 1 and " + strconv.Itoa(related.MaxResults) + "."})
+		}
+		limit = n
 	}
 
-	status, err = post.Delete()
+	post, err := model.PeekPost(id)
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message = true, http.StatusText(status)
-	return c.JSON(status, resp)
-}
+	if !post.Meta().Release {
+		return errs.NotFound(nil)
+	}
 
-// HACK to (temporarily) fix cyclomatic complexity.
-func checkDeletePermissions(post model.Post, role model.UserRole) int {
-	switch v := post.(type) {
-	case *model.Article:
-		if (!v.Release && !RBAC.IsGranted(string(role), permissionDraftOps, nil)) ||
-			(v.Release && !RBAC.IsGranted(string(role), permissionPostOps, nil)) {
-			return http.StatusForbidden
-		}
-	case *model.Gallery:
-		if (!v.Release && !RBAC.IsGranted(string(role), permissionDraftOps, nil)) ||
-			(v.Release && !RBAC.IsGranted(string(role), permissionPostOps, nil)) {
-			return http.StatusForbidden
-		}
-	case *model.Flicker:
-		if (!v.Release && !RBAC.IsGranted(string(role), permissionDraftOps, nil)) ||
-			(v.Release && !RBAC.IsGranted(string(role), permissionPostOps, nil)) {
-			return http.StatusForbidden
-		}
-	default:
-		return http.StatusInternalServerError
+	posts, err := related.For(post, limit)
+	if err != nil {
+		return err
 	}
 
-	return http.StatusOK
+	status := http.StatusOK
+	return c.JSON(status, PostsResponse{Response: ok(status), Posts: posts})
 }
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/l3njo/yap/related"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestGetRelatedPosts(t *testing.T) {
+	e := newTestEcho()
+	e.HTTPErrorHandler = ErrorHandler
+	max := strconv.Itoa(related.MaxResults)
+
+	for _, limit := range []string{"0", strconv.Itoa(related.MaxResults + 1), "many"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts/"+uuid.NewV4().String()+"/related?limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "between 1 and "+max+".") {
+			t.Errorf("GetRelatedPosts() with limit %s = %v %s, want the range up to %s", limit, rec.Code, rec.Body, max)
+		}
+	}
+}
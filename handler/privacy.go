@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// writeExport writes x to w as a ZIP archive with one JSON file per kind of data
+func writeExport(w io.Writer, x model.UserExport) error {
+	z := zip.NewWriter(w)
+	for _, f := range []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", x.Profile},
+		{"posts/articles.json", x.Articles},
+		{"posts/galleries.json", x.Galleries},
+		{"posts/flickers.json", x.Flickers},
+		{"reactions.json", x.Reactions},
+		{"follows.json", x.Follows},
+		{"lists.json", x.Lists},
+		{"identities.json", x.Identities},
+		{"media.json", x.Media},
+	} {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// ExportUser handles the "/users/restricted/me/export" route.
+// It sends a ZIP archive of everything yap holds about the caller.
+func ExportUser(c echo.Context) error {
+	x, err := model.ExportUser(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="yap-export.zip"`)
+	res.WriteHeader(http.StatusOK)
+	return writeExport(res, x)
+}
+
+// EraseUser handles the "/users/restricted/:id/erase" route.
+// Unlike DeleteUser, it cannot be undone.
+func EraseUser(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if !RBAC.IsGranted(string(claims.Role), permissionUserOps, nil) && !uuid.Equal(claims.User, id) {
+		return errs.Forbidden()
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); errs.From(err).Status == http.StatusNotFound {
+		err = user.ReadTrashed()
+		if err != nil {
+			return errs.NotFound(err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if user.Role == model.UserKeeper && user.DeletedAt == nil {
+		if err := checkSoleKeeper(); err != nil {
+			return err
+		}
+	}
+
+	if err := user.Erase(actorOf(c)); err != nil {
+		return err
+	}
+
+	event.Publish(event.New(event.UserErased, claims.User, user.ID))
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status)})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestWriteExport(t *testing.T) {
+	post := uuid.NewV4()
+	x := model.UserExport{
+		Profile: model.User{Name: "Ada"},
+		Media:   []model.MediaRef{{Post: post, URL: "https://example.com/a.png"}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeExport(buf, x); err != nil {
+		t.Fatalf("writeExport() error = %v", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	want := []string{"profile.json", "posts/articles.json", "posts/galleries.json", "posts/flickers.json", "reactions.json", "follows.json", "lists.json", "identities.json", "media.json"}
+	if len(z.File) != len(want) {
+		t.Fatalf("writeExport() wrote %d files, want %d", len(z.File), len(want))
+	}
+	for i, f := range z.File {
+		if f.Name != want[i] {
+			t.Errorf("writeExport() file %d = %q, want %q", i, f.Name, want[i])
+		}
+	}
+
+	r, err := z.File[len(z.File)-1].Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer r.Close()
+
+	media := []model.MediaRef{}
+	if err := json.NewDecoder(r).Decode(&media); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(media) != 1 || !uuid.Equal(media[0].Post, post) || media[0].URL != x.Media[0].URL {
+		t.Errorf("media.json = %v, want %v", media, x.Media)
+	}
+}
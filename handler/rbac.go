@@ -12,6 +12,9 @@ var (
 	permissionUserOps     gorbac.Permission
 	permissionDraftOps    gorbac.Permission
 	permissionReactionOps gorbac.Permission
+	permissionHookOps     gorbac.Permission
+	permissionMarkerOps   gorbac.Permission
+	permissionAuditOps    gorbac.Permission
 )
 
 // InitRBAC initializes the Role-Based Access Control
@@ -28,9 +31,15 @@ func InitRBAC() error {
 	permissionUserOps = gorbac.NewStdPermission("userOps")         // Delete, Assign user
 	permissionDraftOps = gorbac.NewStdPermission("draftOps")       // Create, Delete draft, Edit draft
 	permissionReactionOps = gorbac.NewStdPermission("reactionOps") // Create, Delete reaction
+	permissionHookOps = gorbac.NewStdPermission("hookOps")         // Manage webhooks and their deliveries
+	permissionMarkerOps = gorbac.NewStdPermission("markerOps")     // Rename, Merge markers across posts
+	permissionAuditOps = gorbac.NewStdPermission("auditOps")       // Read, Export the audit log
 
 	_ = roleKeeper.Assign(permissionPostOps)
 	_ = roleKeeper.Assign(permissionUserOps)
+	_ = roleKeeper.Assign(permissionHookOps)
+	_ = roleKeeper.Assign(permissionMarkerOps)
+	_ = roleKeeper.Assign(permissionAuditOps)
 	_ = roleEditor.Assign(permissionDraftOps)
 	_ = roleReader.Assign(permissionReactionOps)
 
@@ -3,7 +3,8 @@ package handler
 import (
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
 	"github.com/l3njo/yap/util"
 	uuid "github.com/satori/go.uuid"
@@ -25,205 +26,151 @@ type ReactionsResponse struct {
 
 // GetPostReactions handles the "/posts/:id/reactions" route.
 func GetPostReactions(c echo.Context) error {
-	resp, status := ReactionsResponse{}, 0
-	reactions, status, err := model.ReadAllReactions()
+	postID, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	postID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(postID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	reactions, err := model.ReadAllReactions()
+	if err != nil {
+		return err
 	}
 
 	reactions = util.FilterR(reactions, func(r model.Reaction) bool {
 		return (r.Site == "blog") && (r.Item == postID)
 	})
 
-	resp.Status, resp.Message, resp.Reactions = true, http.StatusText(status), reactions
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ReactionsResponse{Response: ok(status), Reactions: reactions})
 }
 
 // GetUserReactions handles the "/users/:id/reactions" route.
 func GetUserReactions(c echo.Context) error {
-	resp, status := ReactionsResponse{}, 0
-	reactions, status, err := model.ReadAllReactions()
+	userID, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	userID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(userID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	reactions, err := model.ReadAllReactions()
+	if err != nil {
+		return err
 	}
 
 	reactions = util.FilterR(reactions, func(r model.Reaction) bool {
 		return (r.Site == "blog") && (r.User == userID)
 	})
 
-	resp.Status, resp.Message, resp.Reactions = true, http.StatusText(status), reactions
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ReactionsResponse{Response: ok(status), Reactions: reactions})
 }
 
-// GetPostReactionByID handles the "/reactions/:id" route.
-func GetPostReactionByID(c echo.Context) error {
-	resp, status := ReactionResponse{}, 0
-	reactionID := uuid.FromStringOrNil(c.Param("reaction"))
-	if uuid.Equal(reactionID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+// readPostReaction loads the Reaction addressed by the ":id" and ":reaction" path parameters
+func readPostReaction(c echo.Context) (model.Reaction, error) {
+	reaction := model.Reaction{Site: "blog"}
+	reactionID, err := paramID(c, "reaction")
+	if err != nil {
+		return reaction, err
+	}
+
+	postID, err := paramID(c, "id")
+	if err != nil {
+		return reaction, err
 	}
 
-	postID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(postID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	reaction.ID = reactionID
+	if err := reaction.Read(); err != nil {
+		return reaction, err
 	}
 
-	reaction := model.Reaction{
-		Base: model.Base{
-			ID: reactionID,
-		},
-		Site: "blog",
-		Item: postID,
+	if !uuid.Equal(reaction.Item, postID) || reaction.Site != "blog" {
+		return reaction, errs.New(http.StatusNotFound, errs.CodeNotFound, "")
 	}
 
-	status, err := reaction.Read()
+	return reaction, nil
+}
+
+// GetPostReactionByID handles the "/reactions/:id" route.
+func GetPostReactionByID(c echo.Context) error {
+	reaction, err := readPostReaction(c)
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Reaction = true, http.StatusText(status), reaction
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ReactionResponse{Response: ok(status), Reaction: reaction})
 }
 
 // CreateReaction handles the "/reactions/create" route.
 func CreateReaction(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := ReactionResponse{}, 0
-	reaction := model.Reaction{}
-	if err := c.Bind(&reaction); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	r := reactionRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
-	reaction.Site = "blog"
-	reaction.Item = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(reaction.Item, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	postID, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	reaction.User = claims.User
-	if status, err := reaction.Create(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	reaction := model.Reaction{Type: r.Type, Text: r.Text}
+	reaction.Site, reaction.Item, reaction.User = "blog", postID, claims.User
+	if err := reaction.Create(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Reaction = true, http.StatusText(status), reaction
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.ReactionCreated, claims.User, reaction))
+
+	status := http.StatusCreated
+	return c.JSON(status, ReactionResponse{Response: ok(status), Reaction: reaction})
 }
 
 // UpdateReaction handles the "/reactions/:id/update" route.
 func UpdateReaction(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := ReactionResponse{}, 0
-	reaction, r := model.Reaction{}, model.Reaction{}
-	if err := c.Bind(&r); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	reaction.Site = "blog"
-	reaction.ID = uuid.FromStringOrNil(c.Param("reaction"))
-	if uuid.Equal(reaction.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	r := reactionUpdateRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
-	reaction.Item = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(reaction.Item, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	if status, err := reaction.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	reaction, err := readPostReaction(c)
+	if err != nil {
+		return err
 	}
 
 	if reaction.User != claims.User {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
 	reaction.Text = r.Text
-	status, err := reaction.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := reaction.Update(); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message, resp.Reaction = true, http.StatusText(status), reaction
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.ReactionUpdated, claims.User, reaction))
+
+	status := http.StatusAccepted
+	return c.JSON(status, ReactionResponse{Response: ok(status), Reaction: reaction})
 }
 
 // DeleteReaction handles the "/reactions/:id/delete" route.
 func DeleteReaction(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-	reaction, resp, status := model.Reaction{Site: "blog"}, ReactionResponse{}, 0
-	reaction.ID = uuid.FromStringOrNil(c.Param("reaction"))
-	if uuid.Equal(reaction.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	reaction.Item = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(reaction.Item, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	status, err := reaction.Read()
+	claims := claimsOf(c)
+	reaction, err := readPostReaction(c)
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
 	if !RBAC.IsGranted(string(claims.Role), permissionReactionOps, nil) && !uuid.Equal(claims.User, reaction.User) {
-		status = http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
-	status, err = reaction.Delete()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := reaction.Delete(actorOf(c)); err != nil {
+		return err
 	}
 
-	resp.Status, resp.Message = true, http.StatusText(status)
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.ReactionDeleted, claims.User, reaction))
+
+	status := http.StatusAccepted
+	return c.JSON(status, ReactionResponse{Response: ok(status)})
 }
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReadingListResponse is a response containing one ReadingList
+type ReadingListResponse struct {
+	Response
+	model.ReadingList `json:"data"`
+}
+
+// ReadingListsResponse is a response containing a slice of ReadingLists
+type ReadingListsResponse struct {
+	Response
+	Lists []model.ReadingList `json:"data"`
+}
+
+// ReadingItemResponse is a response containing one ReadingItem
+type ReadingItemResponse struct {
+	Response
+	model.ReadingItem `json:"data"`
+}
+
+// readReadingList loads the caller's ReadingList addressed by the ":id" path parameter.
+// Other Users' lists are reported as missing.
+func readReadingList(c echo.Context) (model.ReadingList, error) {
+	list := model.ReadingList{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return list, err
+	}
+
+	list.ID = id
+	if err := list.Read(); err != nil {
+		return list, err
+	}
+
+	if !uuid.Equal(list.User, claimsOf(c).User) {
+		return list, errs.NotFound(nil)
+	}
+	return list, nil
+}
+
+// checkSaveable reports a missing post, or one the caller cannot read, as invalid
+func checkSaveable(c echo.Context, id uuid.UUID) error {
+	post, err := model.PeekPost(id)
+	if errs.From(err).Status == http.StatusNotFound {
+		return errs.Invalid(errs.Field{Name: "post", Code: errs.FieldInvalid, Message: "No such post."})
+	} else if err != nil {
+		return err
+	}
+
+	if ok, err := canReadPost(post.Meta(), claimsOf(c)); err != nil {
+		return err
+	} else if !ok {
+		return errs.Invalid(errs.Field{Name: "post", Code: errs.FieldInvalid, Message: "No such post."})
+	}
+	return nil
+}
+
+// GetLists handles the "/lists" route.
+func GetLists(c echo.Context) error {
+	lists, err := model.ReadUserLists(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, ReadingListsResponse{Response: ok(status), Lists: lists})
+}
+
+// GetListByID handles the "/lists/:id" route.
+func GetListByID(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// GetSharedList handles the "/lists/shared/:token" route.
+func GetSharedList(c echo.Context) error {
+	list, err := model.ReadSharedList(c.Param("token"))
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// CreateList handles the "/lists/create" route.
+func CreateList(c echo.Context) error {
+	r := listRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	list := model.ReadingList{User: claimsOf(c).User, Name: r.Name}
+	if r.Shared {
+		share, err := newSecret()
+		if err != nil {
+			return err
+		}
+		list.Share = share
+	}
+
+	if err := list.Create(); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// UpdateList handles the "/lists/:id/update" route.
+// Sharing a list again keeps its link; unsharing it revokes the link.
+func UpdateList(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	r := listRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if r.Shared && list.Bookmarks {
+		return errs.Invalid(errs.Field{Name: "shared", Code: errs.FieldInvalid, Message: "Bookmarks are private."})
+	}
+
+	list.Name = r.Name
+	switch {
+	case !r.Shared:
+		list.Share = ""
+	case list.Share == "":
+		if list.Share, err = newSecret(); err != nil {
+			return err
+		}
+	}
+
+	if err := list.Update(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// DeleteList handles the "/lists/:id/delete" route.
+func DeleteList(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	if list.Bookmarks {
+		return errs.New(http.StatusUnprocessableEntity, errs.CodeNotEditable, "Bookmarks cannot be deleted.")
+	}
+
+	if err := list.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// CreateListItem handles the "/lists/:id/items/create" route.
+func CreateListItem(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	return addListItem(c, list)
+}
+
+// DeleteListItem handles the "/lists/:id/items/:item/delete" route.
+func DeleteListItem(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	item, err := paramID(c, "item")
+	if err != nil {
+		return err
+	}
+
+	if err := list.RemoveItem(item); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// OrderListItems handles the "/lists/:id/items/order" route.
+func OrderListItems(c echo.Context) error {
+	list, err := readReadingList(c)
+	if err != nil {
+		return err
+	}
+
+	r := listOrderRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if err := list.Reorder(r.Items); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// GetBookmarks handles the "/users/restricted/me/bookmarks" route.
+func GetBookmarks(c echo.Context) error {
+	list, err := model.UserBookmarks(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, ReadingListResponse{Response: ok(status), ReadingList: list})
+}
+
+// CreateBookmark handles the "/users/restricted/me/bookmarks/create" route.
+func CreateBookmark(c echo.Context) error {
+	list, err := model.UserBookmarks(claimsOf(c).User)
+	if err != nil {
+		return err
+	}
+
+	return addListItem(c, list)
+}
+
+// addListItem saves the post named in the request body to list
+func addListItem(c echo.Context, list model.ReadingList) error {
+	r := listItemRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if err := checkSaveable(c, r.Post); err != nil {
+		return err
+	}
+
+	item, err := list.AddItem(r.Post)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, ReadingItemResponse{Response: ok(status), ReadingItem: item})
+}
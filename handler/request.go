@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/render"
+	uuid "github.com/satori/go.uuid"
+)
+
+// joinRequest is the body of the "/users/join" route.
+type joinRequest struct {
+	Handle string `json:"handle" validate:"omitempty,handle"`
+	Name   string `json:"name" validate:"required,max=64"`
+	Mail   string `json:"mail" validate:"required,email,max=254"`
+	Pass   string `json:"pass" validate:"required,password"`
+	Life   string `json:"life" validate:"max=500"`
+}
+
+// authRequest is the body of the "/users/auth" route.
+type authRequest struct {
+	Mail string `json:"mail" validate:"required,email"`
+	Pass string `json:"pass" validate:"required"`
+}
+
+// userRequest is the body of the "/users/restricted/me/update" route.
+// Every field left out is left alone. Handle, Name and Mail cannot be
+// emptied; the profile fields Life, Avatar, Links, Location and Hidden are
+// cleared when sent empty. Avatar is the address of an image on a MediaHost.
+type userRequest struct {
+	Handle   string               `json:"handle" validate:"omitempty,handle"`
+	Name     string               `json:"name" validate:"omitempty,max=64"`
+	Mail     string               `json:"mail" validate:"omitempty,email,max=254"`
+	Life     *string              `json:"life" validate:"omitempty,max=500"`
+	Avatar   *string              `json:"avatar" validate:"omitempty,media,max=2048"`
+	Links    []string             `json:"links" validate:"max=5,dive,required,url,max=2048"`
+	Location *string              `json:"location" validate:"omitempty,max=64"`
+	Hidden   []model.ProfileField `json:"hidden" validate:"dive,oneof=life avatar links location"`
+}
+
+// passRequest is the body of the "/users/restricted/me/change" route.
+type passRequest struct {
+	Current string `json:"current" validate:"required"`
+	Updated string `json:"updated" validate:"required,password"`
+}
+
+// assignRequest is the body of the "/users/restricted/:id/assign" route.
+type assignRequest struct {
+	Role model.UserRole `json:"role" validate:"required,oneof=reader editor keeper"`
+}
+
+// postRequest holds the fields shared by all post create requests.
+type postRequest struct {
+	Subject string   `json:"subject" validate:"required,max=160"`
+	Summary string   `json:"summary" validate:"max=500"`
+	Overlay string   `json:"overlay" validate:"omitempty,url,max=2048"`
+	Section string   `json:"section" validate:"max=64"`
+	Markers []string `json:"markers" validate:"max=10,dive,required,marker"`
+}
+
+// postUpdateRequest holds the fields shared by all post update requests.
+// Version is the version of the post the edit was made against.
+type postUpdateRequest struct {
+	Subject string   `json:"subject" validate:"max=160"`
+	Summary string   `json:"summary" validate:"max=500"`
+	Overlay string   `json:"overlay" validate:"omitempty,url,max=2048"`
+	Section string   `json:"section" validate:"max=64"`
+	Markers []string `json:"markers" validate:"max=10,dive,required,marker"`
+	Version int      `json:"version" validate:"required,min=1"`
+}
+
+// apply copies the allowed fields of a postRequest into a PostBase.
+func (r postRequest) apply(pb *model.PostBase) {
+	pb.Subject, pb.Summary, pb.Overlay, pb.Section, pb.Markers = r.Subject, r.Summary, r.Overlay, r.Section, r.Markers
+}
+
+// apply copies the allowed fields of a postUpdateRequest into a PostBase.
+func (r postUpdateRequest) apply(pb *model.PostBase) {
+	pb.Subject, pb.Summary, pb.Overlay, pb.Section, pb.Markers = r.Subject, r.Summary, r.Overlay, r.Section, r.Markers
+	pb.Version = r.Version
+}
+
+// articleRequest is the body of the "/posts/articles/create" route.
+type articleRequest struct {
+	postRequest
+	Format  render.Format `json:"format" validate:"omitempty,oneof=markdown html plain"`
+	Content string        `json:"content" validate:"required,max=100000"`
+}
+
+// articleUpdateRequest is the body of the "/posts/articles/:id/update" route.
+type articleUpdateRequest struct {
+	postUpdateRequest
+	Format  render.Format `json:"format" validate:"omitempty,oneof=markdown html plain"`
+	Content string        `json:"content" validate:"max=100000"`
+}
+
+// galleryRequest is the body of the "/posts/galleries/create" route.
+type galleryRequest struct {
+	postRequest
+	Content []string `json:"content" validate:"required,min=1,max=50,dive,required,url,max=255"`
+	Caption []string `json:"caption" validate:"max=50,dive,max=255"`
+}
+
+// galleryUpdateRequest is the body of the "/posts/galleries/:id/update" route.
+type galleryUpdateRequest struct {
+	postUpdateRequest
+	Content []string `json:"content" validate:"max=50,dive,required,url,max=255"`
+	Caption []string `json:"caption" validate:"max=50,dive,max=255"`
+}
+
+// flickerRequest is the body of the "/posts/flickers/create" route.
+type flickerRequest struct {
+	postRequest
+	Content string `json:"content" validate:"required,url,max=2048"`
+	Caption string `json:"caption" validate:"max=500"`
+}
+
+// flickerUpdateRequest is the body of the "/posts/flickers/:id/update" route.
+type flickerUpdateRequest struct {
+	postUpdateRequest
+	Content string `json:"content" validate:"omitempty,url,max=2048"`
+	Caption string `json:"caption" validate:"max=500"`
+}
+
+// transferRequest is the body of the "/posts/*/:id/transfer" routes.
+type transferRequest struct {
+	Creator uuid.UUID `json:"creator" validate:"required"`
+}
+
+// reactionRequest is the body of the "/posts/:id/reactions/restricted/create" route.
+type reactionRequest struct {
+	Type model.ReactionType `json:"type" validate:"required,oneof=approve sticker comment"`
+	Text string             `json:"text" validate:"required_if=Type comment,max=5000"`
+}
+
+// reactionUpdateRequest is the body of the "/posts/:id/reactions/restricted/:reaction/update" route.
+type reactionUpdateRequest struct {
+	Text string `json:"text" validate:"required,max=5000"`
+}
+
+// webhookRequest is the body of the "/hooks/create" and "/hooks/:id/update" routes.
+type webhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,event"`
+	Active *bool    `json:"active"`
+}
+
+// preferencesRequest is the body of the "/users/restricted/me/notifications/preferences" route.
+type preferencesRequest struct {
+	Preferences map[model.NotificationKind]bool `json:"preferences" validate:"required,dive,keys,oneof=comment approval sticker transfer publish retract role invite review,endkeys"`
+}
+
+// followRequest is the body of the "/users/restricted/me/follows/create" route.
+// Users are followed through "/users/restricted/:id/follow".
+type followRequest struct {
+	Kind   model.FollowKind `json:"kind" validate:"required,oneof=section marker"`
+	Target string           `json:"target" validate:"required,max=255"`
+}
+
+// listRequest is the body of the "/lists/create" and "/lists/:id/update" routes.
+type listRequest struct {
+	Name   string `json:"name" validate:"required,max=100"`
+	Shared bool   `json:"shared"`
+}
+
+// listItemRequest is the body of the routes that save a post to a ReadingList.
+type listItemRequest struct {
+	Post uuid.UUID `json:"post" validate:"required"`
+}
+
+// listOrderRequest is the body of the "/lists/:id/items/order" route.
+type listOrderRequest struct {
+	Items []uuid.UUID `json:"items" validate:"required"`
+}
+
+// seriesRequest is the body of the "/series/create" and "/series/:id/update" routes.
+type seriesRequest struct {
+	Name    string `json:"name" validate:"required,max=255"`
+	Summary string `json:"summary" validate:"max=1000"`
+}
+
+// seriesPostRequest is the body of the "/series/:id/posts/create" route.
+type seriesPostRequest struct {
+	Post uuid.UUID `json:"post" validate:"required"`
+}
+
+// seriesOrderRequest is the body of the "/series/:id/posts/order" route.
+type seriesOrderRequest struct {
+	Posts []uuid.UUID `json:"posts" validate:"required"`
+}
+
+// markerRenameRequest is the body of the "/markers/restricted/:name/rename" route.
+type markerRenameRequest struct {
+	To string `json:"to" validate:"required,marker"`
+}
+
+// markerMergeRequest is the body of the "/markers/restricted/merge" route.
+type markerMergeRequest struct {
+	From []string `json:"from" validate:"required,min=1,max=50,dive,required,marker"`
+	To   string   `json:"to" validate:"required,marker"`
+}
+
+// authorRequest is the body of the "/posts/:id/authors/invite" route.
+type authorRequest struct {
+	User uuid.UUID        `json:"user" validate:"required"`
+	Role model.AuthorRole `json:"role" validate:"required,oneof=owner coauthor reviewer"`
+}
+
+// reviewRequest is the optional body of the "/posts/:id" workflow routes.
+type reviewRequest struct {
+	Comment string `json:"comment" validate:"max=2000"`
+}
+
+// changesRequest is the body of the "/posts/:id/request-changes" route.
+type changesRequest struct {
+	Comment string `json:"comment" validate:"required,max=2000"`
+}
+
+// challengeRequest is the body of the "/users/auth/2fa" route.
+type challengeRequest struct {
+	Challenge string `json:"challenge" validate:"required"`
+	Code      string `json:"code" validate:"required,max=32"`
+}
+
+// codeRequest is the body of the "/users/restricted/me/2fa" routes that need a current code.
+type codeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// twoFactorPolicyRequest is the body of the "/admin/2fa/update" route.
+type twoFactorPolicyRequest struct {
+	Roles []model.UserRole `json:"roles" validate:"dive,oneof=reader editor keeper"`
+}
+
+// tokenRequest is the body of the "/users/restricted/me/tokens/create" route.
+type tokenRequest struct {
+	Name   string        `json:"name" validate:"required,max=100"`
+	Scopes []model.Scope `json:"scopes" validate:"required,min=1,dive,oneof=account:read account:write posts:read posts:write reactions:write lists:read lists:write hooks admin"`
+	Days   int           `json:"days" validate:"omitempty,min=1,max=365"`
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Routes registers every API route on e.
+// Restricted groups authenticate with a JWT signed by Keys or a personal
+// access token, then check the token's session, the two-factor policy and
+// token scopes. Tokens may only use routes wrapped in requireScope.
+// Streams also accept a StreamTicket in a "ticket" query parameter,
+// since browsers cannot set headers on EventSource or WebSocket requests.
+func Routes(e *echo.Echo, jwtConfig middleware.JWTConfig) {
+	jwtConfig.ParseTokenFunc = parseAuth
+	streamConfig := jwtConfig
+	streamConfig.ParseTokenFunc = parseStreamAuth
+	streamConfig.TokenLookup = "header:" + echo.HeaderAuthorization + ",query:ticket"
+	streamed := []echo.MiddlewareFunc{middleware.JWTWithConfig(streamConfig), checkSession, twoFactorPolicy, tokenScope}
+	restricted := []echo.MiddlewareFunc{middleware.JWTWithConfig(jwtConfig), checkSession, twoFactorPolicy, tokenScope}
+
+	e.GET("/", AppController)
+	e.GET("/openapi.json", GetOpenAPI)
+	e.GET("/docs", GetDocs)
+	e.GET("/docs/redoc.js", GetDocsScript)
+	e.GET("/.well-known/jwks.json", GetJWKS)
+
+	// PATH /users
+	u := e.Group("/users")
+	u.GET("", GetUsers)
+	u.GET("/:id", GetUserByID)
+	u.GET("/by-handle/:handle", GetUserByHandle)
+	u.POST("/join", JoinUser, limitBy("join", joinRate, byIP))
+	u.POST("/auth", AuthUser, limitBy("auth", authRate, byIP))
+	u.POST("/auth/2fa", VerifyTwoFactor, limitBy("auth", authRate, byIP))
+	u.GET("/oidc", GetProviders)
+	u.GET("/oidc/:provider/login", LoginProvider, limitBy("auth", authRate, byIP))
+	u.GET("/oidc/:provider/callback", ProviderCallback, limitBy("auth", authRate, byIP))
+	u.GET("/:id/posts/articles", GetUserPublicArticles)
+	u.GET("/:id/posts/galleries", GetUserPublicGalleries)
+	u.GET("/:id/posts/flickers", GetUserPublicFlickers)
+	u.GET("/:id/reactions", GetUserReactions)
+	u.GET("/:id/followers", GetUserFollowers)
+	u.GET("/:id/following", GetUserFollowing)
+	requireScope(model.ScopeAccountRead, u.GET("/restricted/me/events", StreamUserEvents, streamed...))
+
+	// PATH /users/restricted
+	uAuth := u.Group("/restricted")
+	uAuth.Use(restricted...)
+	// uAuth.GET("/:id/posts/articles", GetUserArticles) // TODO
+	// uAuth.GET("/:id/posts/galleries", GetUserGalleries) // TODO
+	// uAuth.GET("/:id/posts/flickers", GetUserFlickers) // TODO
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/me/update", UpdateUser))
+	uAuth.PUT("/me/change", UpdatePass)
+	requireScope(model.ScopeAccountRead, uAuth.GET("/me/notifications", GetNotifications))
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/me/notifications/read", ReadAllNotifications))
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/me/notifications/:id/read", ReadNotification))
+	requireScope(model.ScopeAccountRead, uAuth.GET("/me/notifications/preferences", GetNotificationPreferences))
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/me/notifications/preferences", UpdateNotificationPreferences))
+	requireScope(model.ScopeAccountRead, uAuth.GET("/me/feed", GetFeed))
+	requireScope(model.ScopeListsRead, uAuth.GET("/me/bookmarks", GetBookmarks))
+	requireScope(model.ScopeListsWrite, uAuth.POST("/me/bookmarks/create", CreateBookmark))
+	requireScope(model.ScopeAccountRead, uAuth.GET("/me/follows", GetFollows))
+	requireScope(model.ScopeAccountWrite, uAuth.POST("/me/follows/create", CreateFollow))
+	requireScope(model.ScopeAccountWrite, uAuth.DELETE("/me/follows/:id/delete", DeleteFollow))
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/:id/follow", FollowUser))
+	requireScope(model.ScopeAccountWrite, uAuth.PUT("/:id/unfollow", UnfollowUser))
+	requireScope(model.ScopeAdmin, uAuth.PUT("/:id/assign", AssignUser))
+	requireScope(model.ScopeAdmin, uAuth.DELETE("/:id/delete", DeleteUser))
+	uAuth.GET("/me/export", ExportUser)
+	uAuth.DELETE("/:id/erase", EraseUser)
+	uAuth.POST("/me/2fa/enroll", EnrollTwoFactor)
+	uAuth.PUT("/me/2fa/enable", EnableTwoFactor)
+	uAuth.PUT("/me/2fa/disable", DisableTwoFactor)
+	uAuth.POST("/me/2fa/recovery", RenewRecoveryCodes)
+	uAuth.GET("/me/tokens", GetAccessTokens)
+	uAuth.POST("/me/tokens/create", CreateAccessToken)
+	uAuth.DELETE("/me/tokens/:id/delete", DeleteAccessToken)
+	uAuth.POST("/me/oidc/:provider/link", LinkProvider)
+	uAuth.POST("/me/streams/ticket", CreateStreamTicket)
+	uAuth.GET("/me/sessions", GetSessions)
+	uAuth.DELETE("/me/sessions/delete", DeleteOtherSessions)
+	uAuth.DELETE("/me/sessions/:id/delete", DeleteSession)
+	requireScope(model.ScopeAdmin, uAuth.GET("/:id/sessions", GetUserSessions))
+	requireScope(model.ScopeAdmin, uAuth.DELETE("/:id/sessions/delete", DeleteUserSessions))
+
+	// PATH /posts
+	p := e.Group("/posts")
+	p.GET("/:id/related", GetRelatedPosts)
+	pAuth := p.Group("/:id")
+	pAuth.Use(restricted...)
+	requireScope(model.ScopePostsWrite, pAuth.DELETE("/delete", DeletePost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/submit", SubmitPost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/approve", ApprovePost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/request-changes", RequestPostChanges))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/publish", PublishPost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/retract", RetractPost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/archive", ArchivePost))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/unarchive", UnarchivePost))
+	requireScope(model.ScopePostsRead, pAuth.GET("/history", GetPostHistory))
+	requireScope(model.ScopePostsRead, pAuth.GET("/authors", GetPostAuthors))
+	requireScope(model.ScopePostsWrite, pAuth.POST("/authors/invite", InvitePostAuthor))
+	requireScope(model.ScopePostsWrite, pAuth.PUT("/authors/accept", AcceptPostAuthor))
+	requireScope(model.ScopePostsWrite, pAuth.DELETE("/authors/:user/delete", RemovePostAuthor))
+
+	// PATH /posts/:id/reactions
+	pr := p.Group("/:id/reactions")
+	pr.GET("", GetPostReactions)
+	requireScope(model.ScopePostsRead, pr.GET("/stream", StreamPostReactions, streamed...))
+	pr.GET("/:reaction", GetPostReactionByID)
+
+	// PATH /posts/:id/reactions/restricted
+	prAuth := pr.Group("/restricted")
+	prAuth.Use(restricted...)
+	requireScope(model.ScopeReactionsWrite, prAuth.POST("/create", CreateReaction, limitBy("reaction", reactionRate, byUser)))
+	requireScope(model.ScopeReactionsWrite, prAuth.PUT("/:reaction/update", UpdateReaction))
+	requireScope(model.ScopeReactionsWrite, prAuth.DELETE("/:reaction/delete", DeleteReaction))
+
+	// PATH /posts/articles
+	a := p.Group("/articles")
+	a.GET("/public", GetPublicArticles)
+	a.GET("/public/:id", GetPublicArticleByID)
+	a.GET("/public/by-slug/:slug", GetPublicArticleBySlug)
+
+	aAuth := a.Group("")
+	aAuth.Use(restricted...)
+	requireScope(model.ScopePostsRead, aAuth.GET("", GetArticles))
+	requireScope(model.ScopePostsRead, aAuth.GET("/:id", GetArticleByID))
+	requireScope(model.ScopePostsWrite, aAuth.POST("/create", CreateArticle))
+	requireScope(model.ScopePostsWrite, aAuth.PUT("/:id/update", UpdateArticle))
+	requireScope(model.ScopePostsWrite, aAuth.PUT("/:id/transfer", TransferArticle))
+
+	// PATH /posts/galleries
+	g := p.Group("/galleries")
+	g.GET("/public", GetPublicGalleries)
+	g.GET("/public/:id", GetPublicGalleryByID)
+	g.GET("/public/by-slug/:slug", GetPublicGalleryBySlug)
+
+	gAuth := g.Group("")
+	gAuth.Use(restricted...)
+	requireScope(model.ScopePostsRead, gAuth.GET("", GetGalleries))
+	requireScope(model.ScopePostsRead, gAuth.GET("/:id", GetGalleryByID))
+	requireScope(model.ScopePostsWrite, gAuth.POST("/create", CreateGallery))
+	requireScope(model.ScopePostsWrite, gAuth.PUT("/:id/update", UpdateGallery))
+	requireScope(model.ScopePostsWrite, gAuth.PUT("/:id/transfer", TransferGallery))
+
+	// PATH /posts/flickers
+	f := p.Group("/flickers")
+	f.GET("/public", GetPublicFlickers)
+	f.GET("/public/:id", GetPublicFlickerByID)
+	f.GET("/public/by-slug/:slug", GetPublicFlickerBySlug)
+
+	fAuth := f.Group("")
+	fAuth.Use(restricted...)
+	requireScope(model.ScopePostsRead, fAuth.GET("", GetFlickers))
+	requireScope(model.ScopePostsRead, fAuth.GET("/:id", GetFlickerByID))
+	requireScope(model.ScopePostsWrite, fAuth.POST("/create", CreateFlicker))
+	requireScope(model.ScopePostsWrite, fAuth.PUT("/:id/update", UpdateFlicker))
+	requireScope(model.ScopePostsWrite, fAuth.PUT("/:id/transfer", TransferFlicker))
+
+	// PATH /markers
+	m := e.Group("/markers")
+	m.GET("", GetMarkers)
+	m.GET("/suggest", SuggestMarkers)
+	m.GET("/:name/posts", GetMarkerPosts)
+
+	mAuth := m.Group("/restricted")
+	mAuth.Use(restricted...)
+	requireScope(model.ScopePostsWrite, mAuth.PUT("/:name/rename", RenameMarker))
+	requireScope(model.ScopePostsWrite, mAuth.PUT("/merge", MergeMarkers))
+
+	// PATH /series
+	// srAuth.Use claims every method on "/series" and "/series/*",
+	// so the public routes are registered after it to take GET back.
+	sr := e.Group("/series")
+	srAuth := sr.Group("")
+	srAuth.Use(restricted...)
+	sr.GET("", GetPublicSeries)
+	sr.GET("/:id", GetPublicSeriesByID)
+	requireScope(model.ScopePostsWrite, srAuth.POST("/create", CreateSeries))
+	requireScope(model.ScopePostsWrite, srAuth.PUT("/:id/update", UpdateSeries))
+	requireScope(model.ScopePostsWrite, srAuth.DELETE("/:id/delete", DeleteSeries))
+	requireScope(model.ScopePostsWrite, srAuth.POST("/:id/posts/create", AddSeriesPost))
+	requireScope(model.ScopePostsWrite, srAuth.DELETE("/:id/posts/:post/delete", RemoveSeriesPost))
+	requireScope(model.ScopePostsWrite, srAuth.PUT("/:id/posts/order", OrderSeriesPosts))
+
+	// PATH /lists
+	l := e.Group("/lists")
+	l.GET("/shared/:token", GetSharedList)
+
+	lAuth := l.Group("")
+	lAuth.Use(restricted...)
+	requireScope(model.ScopeListsRead, lAuth.GET("", GetLists))
+	requireScope(model.ScopeListsWrite, lAuth.POST("/create", CreateList))
+	requireScope(model.ScopeListsRead, lAuth.GET("/:id", GetListByID))
+	requireScope(model.ScopeListsWrite, lAuth.PUT("/:id/update", UpdateList))
+	requireScope(model.ScopeListsWrite, lAuth.DELETE("/:id/delete", DeleteList))
+	requireScope(model.ScopeListsWrite, lAuth.POST("/:id/items/create", CreateListItem))
+	requireScope(model.ScopeListsWrite, lAuth.DELETE("/:id/items/:item/delete", DeleteListItem))
+	requireScope(model.ScopeListsWrite, lAuth.PUT("/:id/items/order", OrderListItems))
+
+	// PATH /admin
+	adm := e.Group("/admin")
+	adm.Use(restricted...)
+	requireScope(model.ScopeAdmin, adm.GET("/audit", GetAuditLog))
+	requireScope(model.ScopeAdmin, adm.GET("/audit/export", ExportAuditLog))
+	requireScope(model.ScopeAdmin, adm.GET("/2fa", GetTwoFactorPolicy))
+	adm.PUT("/2fa/update", UpdateTwoFactorPolicy)
+
+	// PATH /trash
+	t := e.Group("/trash")
+	t.Use(restricted...)
+	requireScope(model.ScopePostsRead, t.GET("/posts", GetTrashedPosts))
+	requireScope(model.ScopeAccountRead, t.GET("/reactions", GetTrashedReactions))
+	requireScope(model.ScopeAdmin, t.GET("/users", GetTrashedUsers))
+	requireScope(model.ScopePostsWrite, t.PUT("/posts/:id/restore", RestoreTrashedPost))
+	requireScope(model.ScopeReactionsWrite, t.PUT("/reactions/:id/restore", RestoreTrashedReaction))
+	requireScope(model.ScopeAdmin, t.PUT("/users/:id/restore", RestoreTrashedUser))
+
+	// PATH /hooks
+	h := e.Group("/hooks")
+	h.Use(restricted...)
+	requireScope(model.ScopeHooks, h.GET("", GetWebhooks))
+	requireScope(model.ScopeHooks, h.POST("/create", CreateWebhook))
+	requireScope(model.ScopeHooks, h.GET("/:id", GetWebhookByID))
+	requireScope(model.ScopeHooks, h.PUT("/:id/update", UpdateWebhook))
+	requireScope(model.ScopeHooks, h.DELETE("/:id/delete", DeleteWebhook))
+	requireScope(model.ScopeHooks, h.GET("/:id/deliveries", GetWebhookDeliveries))
+	requireScope(model.ScopeHooks, h.POST("/:id/deliveries/:delivery/redeliver", RedeliverWebhook))
+}
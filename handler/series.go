@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SeriesResponse is a response containing one Series
+type SeriesResponse struct {
+	Response
+	model.Series `json:"data"`
+}
+
+// SeriesListResponse is a response containing a slice of Series
+type SeriesListResponse struct {
+	Response
+	Series []model.Series `json:"data"`
+}
+
+// canEditSeries reports whether claims may change s:
+// its creator may, as may anyone allowed to edit released posts.
+func canEditSeries(s *model.Series, claims *JwtCustomClaims) bool {
+	return uuid.Equal(s.Creator, claims.User) || RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)
+}
+
+// readEditableSeries loads the Series addressed by the ":id" path parameter
+// if the caller may change it.
+func readEditableSeries(c echo.Context) (model.Series, error) {
+	s := model.Series{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return s, err
+	}
+
+	s.ID = id
+	if err := s.Read(false); err != nil {
+		return s, err
+	}
+
+	if !canEditSeries(&s, claimsOf(c)) {
+		return s, errs.Forbidden()
+	}
+	return s, nil
+}
+
+// GetPublicSeries handles the "/series" route.
+func GetPublicSeries(c echo.Context) error {
+	series, err := model.ReadAllSeries()
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, SeriesListResponse{Response: ok(status), Series: series})
+}
+
+// GetPublicSeriesByID handles the "/series/:id" route.
+func GetPublicSeriesByID(c echo.Context) error {
+	s := model.Series{}
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	s.ID = id
+	if err := s.Read(true); err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, SeriesResponse{Response: ok(status), Series: s})
+}
+
+// CreateSeries handles the "/series/create" route.
+func CreateSeries(c echo.Context) error {
+	claims := claimsOf(c)
+	r := seriesRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if !RBAC.IsGranted(string(claims.Role), permissionDraftOps, nil) {
+		return errs.Forbidden()
+	}
+
+	s := model.Series{Name: r.Name, Summary: r.Summary, Creator: claims.User}
+	if err := s.Create(); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, SeriesResponse{Response: ok(status), Series: s})
+}
+
+// UpdateSeries handles the "/series/:id/update" route.
+func UpdateSeries(c echo.Context) error {
+	s, err := readEditableSeries(c)
+	if err != nil {
+		return err
+	}
+
+	r := seriesRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	s.Name, s.Summary = r.Name, r.Summary
+	if err := s.Update(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, SeriesResponse{Response: ok(status), Series: s})
+}
+
+// DeleteSeries handles the "/series/:id/delete" route.
+func DeleteSeries(c echo.Context) error {
+	s, err := readEditableSeries(c)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// AddSeriesPost handles the "/series/:id/posts/create" route.
+// The caller must be able to edit both the Series and the post.
+func AddSeriesPost(c echo.Context) error {
+	claims := claimsOf(c)
+	s, err := readEditableSeries(c)
+	if err != nil {
+		return err
+	}
+
+	r := seriesPostRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(r.Post)
+	if errs.From(err).Status == http.StatusNotFound {
+		return errs.Invalid(errs.Field{Name: "post", Code: errs.FieldInvalid, Message: "No such post."})
+	} else if err != nil {
+		return err
+	}
+
+	if ok, err := canWritePost(post.Meta(), claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
+	}
+
+	if err := s.AddPost(r.Post); err != nil {
+		return err
+	}
+
+	if err := s.Read(false); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, SeriesResponse{Response: ok(status), Series: s})
+}
+
+// RemoveSeriesPost handles the "/series/:id/posts/:post/delete" route.
+func RemoveSeriesPost(c echo.Context) error {
+	s, err := readEditableSeries(c)
+	if err != nil {
+		return err
+	}
+
+	post, err := paramID(c, "post")
+	if err != nil {
+		return err
+	}
+
+	if err := s.RemovePost(post); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// OrderSeriesPosts handles the "/series/:id/posts/order" route.
+func OrderSeriesPosts(c echo.Context) error {
+	s, err := readEditableSeries(c)
+	if err != nil {
+		return err
+	}
+
+	r := seriesOrderRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if err := s.Reorder(r.Posts); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, SeriesResponse{Response: ok(status), Series: s})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// SessionsResponse is a response containing Sessions
+type SessionsResponse struct {
+	Response
+	Sessions []model.Session `json:"data"`
+}
+
+// RevokedResponse is a response containing how many Sessions were revoked
+type RevokedResponse struct {
+	Response
+	Revoked int64 `json:"data"`
+}
+
+// sessionOf returns the Session that authenticated a request, if any
+func sessionOf(c echo.Context) uuid.UUID {
+	return uuid.FromStringOrNil(claimsOf(c).Id)
+}
+
+// checkSession rejects JWTs whose Session was revoked. Tokens issued before
+// Sessions existed carry none, and are let through until they expire.
+func checkSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := claimsOf(c)
+		if claims.Token != nil {
+			return next(c)
+		}
+
+		id := sessionOf(c)
+		if uuid.Equal(id, uuid.Nil) {
+			if claims.IssuedAt != 0 && !time.Unix(claims.IssuedAt, 0).Before(model.SessionsSince()) {
+				return errs.New(http.StatusUnauthorized, errs.CodeUnauthorized, "This token has no session. Log in again.")
+			}
+			return next(c)
+		}
+
+		if err := model.TouchSession(id, claims.User, c.RealIP()); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// readSessions responds with the Sessions of user, marking the caller's
+func readSessions(c echo.Context, user model.User) error {
+	sessions, err := user.ReadSessions()
+	if err != nil {
+		return err
+	}
+
+	current := sessionOf(c)
+	for i := range sessions {
+		sessions[i].Current = uuid.Equal(sessions[i].ID, current)
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, SessionsResponse{Response: ok(status), Sessions: sessions})
+}
+
+// GetSessions handles the "/users/restricted/me/sessions" route.
+func GetSessions(c echo.Context) error {
+	return readSessions(c, model.User{Base: model.Base{ID: claimsOf(c).User}})
+}
+
+// DeleteSession handles the "/users/restricted/me/sessions/:id/delete" route.
+// Deleting the current Session logs out.
+func DeleteSession(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	s := model.Session{Base: model.Base{ID: id}, User: claimsOf(c).User}
+	if err := s.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
+
+// DeleteOtherSessions handles the "/users/restricted/me/sessions/delete" route.
+// It logs the caller out everywhere else.
+func DeleteOtherSessions(c echo.Context) error {
+	user := model.User{Base: model.Base{ID: claimsOf(c).User}}
+	revoked, err := user.RevokeSessions(sessionOf(c), actorOf(c))
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, RevokedResponse{Response: ok(status), Revoked: revoked})
+}
+
+// GetUserSessions handles the "/users/restricted/:id/sessions" route.
+func GetUserSessions(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionUserOps, nil) {
+		return errs.Forbidden()
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); err != nil {
+		return err
+	}
+	return readSessions(c, user)
+}
+
+// DeleteUserSessions handles the "/users/restricted/:id/sessions/delete" route.
+// It logs a User out everywhere.
+func DeleteUserSessions(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionUserOps, nil) {
+		return errs.Forbidden()
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); err != nil {
+		return err
+	}
+
+	revoked, err := user.RevokeSessions(uuid.Nil, actorOf(c))
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, RevokedResponse{Response: ok(status), Revoked: revoked})
+}
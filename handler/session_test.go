@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCheckSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *JwtCustomClaims
+		status int
+	}{
+		{name: "Old Token Test", claims: &JwtCustomClaims{}, status: http.StatusOK},
+		{name: "Sessionless Token Test", claims: &JwtCustomClaims{StandardClaims: jwt.StandardClaims{IssuedAt: time.Now().Unix()}}, status: http.StatusUnauthorized},
+		{name: "Access Token Test", claims: &JwtCustomClaims{Token: &model.AccessToken{}}, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = ErrorHandler
+			withClaims := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					tt.claims.User = uuid.NewV4()
+					c.Set("user", &jwt.Token{Claims: tt.claims})
+					return next(c)
+				}
+			}
+			e.GET("/", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, withClaims, checkSession)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.status {
+				t.Errorf("checkSession() status = %v, want %v", rec.Code, tt.status)
+			}
+		})
+	}
+}
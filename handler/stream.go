@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/limit"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/stream"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/websocket"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Stream timing
+const (
+	// heartbeat is how often an idle stream is pinged
+	heartbeat = 25 * time.Second
+	// writeTimeout is how long one write may block before the client is
+	// treated as a slow consumer and disconnected
+	writeTimeout = 10 * time.Second
+)
+
+// ticketLife is how long a stream ticket can be used
+const ticketLife = 30 * time.Second
+
+// StreamTicket lets one stream connect with a "ticket" query parameter,
+// since browsers cannot set headers on EventSource or WebSocket requests.
+// Unlike a token, it is harmless once it shows up in an access log.
+type StreamTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StreamTicketResponse is a response containing a StreamTicket
+type StreamTicketResponse struct {
+	Response
+	StreamTicket `json:"data"`
+}
+
+// ticketClaims are the auth claims of the User a ticket was made for.
+// Nonce makes each ticket single use.
+type ticketClaims struct {
+	JwtCustomClaims
+	Nonce string `json:"nonce"`
+}
+
+// streamMessage is a WebSocket frame
+type streamMessage struct {
+	Type  event.Type   `json:"type"`
+	Event *event.Event `json:"event,omitempty"`
+}
+
+// Control frames sent on a WebSocket
+const (
+	heartbeatMessage event.Type = "heartbeat"
+	overflowMessage  event.Type = "overflow"
+)
+
+// CreateStreamTicket handles the "/users/restricted/me/streams/ticket" route.
+func CreateStreamTicket(c echo.Context) error {
+	st := StreamTicket{ExpiresAt: time.Now().Add(ticketLife).UTC()}
+	claims := &ticketClaims{JwtCustomClaims: *claimsOf(c), Nonce: uuid.NewV4().String()}
+	claims.Audience, claims.ExpiresAt = audTicket, st.ExpiresAt.Unix()
+
+	var err error
+	if st.Ticket, err = signFor(claims); err != nil {
+		return errs.Internal(err)
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, StreamTicketResponse{Response: ok(status), StreamTicket: st})
+}
+
+// parseStreamAuth is the ParseTokenFunc of streams. They take an auth token
+// in the header like other routes, or a ticket in the query, used up once read.
+func parseStreamAuth(auth string, c echo.Context) (interface{}, error) {
+	if auth != c.QueryParam("ticket") {
+		return parseAuth(auth, c)
+	}
+
+	claims := &ticketClaims{}
+	if err := parseFor(auth, audTicket, claims); err != nil {
+		return nil, err
+	}
+	if wait := LimitStore.Take("ticket:"+claims.Nonce, limit.Rate{Burst: 1, Every: ticketLife}, time.Now()); wait > 0 {
+		return nil, errors.New("ticket already used")
+	}
+	return &jwt.Token{Claims: &claims.JwtCustomClaims, Valid: true}, nil
+}
+
+// StreamPostReactions handles the "/posts/:id/reactions/stream" route.
+func StreamPostReactions(c echo.Context) error {
+	claims := claimsOf(c)
+	postID, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(postID)
+	if err != nil {
+		return err
+	}
+
+	meta := post.Meta()
+	if !meta.Release && !uuid.Equal(meta.Creator, claims.User) && !canEditPost(meta, claims) {
+		return errs.NotFound(nil)
+	}
+
+	return serveStream(c, func(e event.Event) bool {
+		r, ok := e.Data.(model.Reaction)
+		return ok && r.Site == "blog" && uuid.Equal(r.Item, postID)
+	})
+}
+
+// StreamUserEvents handles the "/users/restricted/me/events" route.
+func StreamUserEvents(c echo.Context) error {
+	user := claimsOf(c).User
+	return serveStream(c, func(e event.Event) bool {
+		n, ok := e.Data.(model.Notification)
+		return ok && e.Type == event.NotificationCreated && uuid.Equal(n.User, user)
+	})
+}
+
+// serveStream sends events matching f over a WebSocket when the client asks
+// to upgrade, and as Server-Sent Events otherwise.
+func serveStream(c echo.Context, f stream.Filter) error {
+	sub := stream.Subscribe(f)
+	defer sub.Close()
+
+	if c.IsWebSocket() {
+		websocket.Handler(func(ws *websocket.Conn) {
+			serveWebSocket(ws, sub)
+		}).ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+	return serveSSE(c, sub)
+}
+
+// serveSSE writes events to c as a text/event-stream
+func serveSSE(c echo.Context, sub *stream.Subscriber) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-sub.Dropped:
+			fmt.Fprintf(res, "event: %s\ndata: {}\n\n", overflowMessage)
+			res.Flush()
+			return nil
+		case <-ticker.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case e := <-sub.C:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return errs.Internal(err)
+			}
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// serveWebSocket writes events to ws as JSON frames. Anything the client
+// sends is discarded; reading only detects when it goes away.
+func serveWebSocket(ws *websocket.Conn, sub *stream.Subscriber) {
+	defer ws.Close()
+
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	send := func(m streamMessage) bool {
+		ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return websocket.JSON.Send(ws, m) == nil
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gone:
+			return
+		case <-sub.Dropped:
+			send(streamMessage{Type: overflowMessage})
+			return
+		case <-ticker.C:
+			if !send(streamMessage{Type: heartbeatMessage}) {
+				return
+			}
+		case e := <-sub.C:
+			if !send(streamMessage{Type: e.Type, Event: &e}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/limit"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/stream"
+	uuid "github.com/satori/go.uuid"
+	"golang.org/x/net/websocket"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newStreamServer serves StreamUserEvents as user, skipping token checks
+func newStreamServer(user uuid.UUID) *httptest.Server {
+	e := echo.New()
+	e.GET("/events", StreamUserEvents, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &jwt.Token{Claims: &JwtCustomClaims{User: user}})
+			return next(c)
+		}
+	})
+	return httptest.NewServer(e)
+}
+
+// notifyEvent is a NotificationCreated event for user
+func notifyEvent(user uuid.UUID, text string) event.Event {
+	return event.New(event.NotificationCreated, uuid.Nil, model.Notification{User: user, Text: text})
+}
+
+func TestStreamUserEvents_SSE(t *testing.T) {
+	user := uuid.NewV4()
+	srv := newStreamServer(user)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if got := res.Header.Get(echo.HeaderContentType); got != "text/event-stream" {
+		t.Errorf("Content-Type = %v, want text/event-stream", got)
+	}
+
+	stream.Dispatch(notifyEvent(uuid.NewV4(), "not yours"))
+	stream.Dispatch(notifyEvent(user, "yours"))
+
+	lines := bufio.NewScanner(res.Body)
+	var got []string
+	for lines.Scan() && lines.Text() != "" {
+		got = append(got, lines.Text())
+	}
+
+	if len(got) != 3 || got[1] != "event: "+string(event.NotificationCreated) {
+		t.Fatalf("event = %q, want id, event and data lines", got)
+	}
+	if !strings.Contains(got[2], `"yours"`) {
+		t.Errorf("data = %v, want the user's notification", got[2])
+	}
+}
+
+func TestStreamUserEvents_WebSocket(t *testing.T) {
+	user := uuid.NewV4()
+	srv := newStreamServer(user)
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/events", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	stream.Dispatch(notifyEvent(uuid.NewV4(), "not yours"))
+	stream.Dispatch(notifyEvent(user, "yours"))
+
+	var m struct {
+		Type  event.Type `json:"type"`
+		Event struct {
+			Data model.Notification `json:"data"`
+		} `json:"event"`
+	}
+	if err := websocket.JSON.Receive(ws, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != event.NotificationCreated || m.Event.Data.Text != "yours" {
+		t.Errorf("message = %+v, want the user's notification", m)
+	}
+}
+
+func TestStreamTicket(t *testing.T) {
+	useTestKeys(t)
+	LimitStore = limit.NewMemoryStore()
+	user := uuid.NewV4()
+	e := echo.New()
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/users/restricted/me/streams/ticket", nil), rec)
+	c.Set("user", &jwt.Token{Claims: &JwtCustomClaims{User: user}})
+	if err := CreateStreamTicket(c); err != nil {
+		t.Fatalf("CreateStreamTicket() error = %v", err)
+	}
+	r := StreamTicketResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+		t.Fatalf("CreateStreamTicket() sent %s: %v", rec.Body, err)
+	}
+
+	inQuery := func(ticket string) echo.Context {
+		return e.NewContext(httptest.NewRequest(http.MethodGet, "/events?ticket="+ticket, nil), httptest.NewRecorder())
+	}
+	if _, err := parseAuth(r.Ticket, inQuery("")); err == nil {
+		t.Error("a stream ticket passed for an auth token")
+	}
+
+	token, err := parseStreamAuth(r.Ticket, inQuery(r.Ticket))
+	if err != nil {
+		t.Fatalf("parseStreamAuth() error = %v", err)
+	}
+	if got := token.(*jwt.Token).Claims.(*JwtCustomClaims).User; got != user {
+		t.Errorf("parseStreamAuth() user = %v, want %v", got, user)
+	}
+	if _, err := parseStreamAuth(r.Ticket, inQuery(r.Ticket)); err == nil {
+		t.Error("parseStreamAuth() accepted a ticket twice")
+	}
+
+	auth, err := signAuth(model.User{Base: model.Base{ID: user}}, false, uuid.NewV4(), time.Now())
+	if err != nil {
+		t.Fatalf("signAuth() error = %v", err)
+	}
+	if _, err := parseStreamAuth(auth, inQuery(auth)); err == nil {
+		t.Error("parseStreamAuth() accepted an auth token in the query")
+	}
+	if _, err := parseStreamAuth(model.TokenPrefix+"secret", inQuery(model.TokenPrefix+"secret")); err == nil {
+		t.Error("parseStreamAuth() accepted a personal access token in the query")
+	}
+}
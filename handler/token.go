@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/keyring"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+)
+
+// tokenDays is how long a personal access token lasts unless asked otherwise
+const tokenDays = 30
+
+// AccessTokenResponse is a response containing an AccessToken
+type AccessTokenResponse struct {
+	Response
+	model.AccessToken `json:"data"`
+}
+
+// AccessTokensResponse is a response containing AccessTokens
+type AccessTokensResponse struct {
+	Response
+	Tokens []model.AccessToken `json:"data"`
+}
+
+// Audiences of the other tokens signed with Keys. Auth tokens have none,
+// so no kind of token can pass for another.
+const (
+	audChallenge = "yap two-factor challenge"
+	audOIDC      = "yap oidc state"
+	audTicket    = "yap stream ticket"
+)
+
+// audienced are claims that name who they are meant for
+type audienced interface {
+	jwt.Claims
+	VerifyAudience(cmp string, req bool) bool
+}
+
+// parseAuth is the ParseTokenFunc of the auth middleware. It accepts JWTs
+// signed with one of Keys as well as personal access tokens.
+func parseAuth(auth string, c echo.Context) (interface{}, error) {
+	if strings.HasPrefix(auth, model.TokenPrefix) {
+		return parseAccessToken(auth)
+	}
+
+	t, err := jwt.ParseWithClaims(auth, &JwtCustomClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if kid, _ := t.Header["kid"].(string); kid == "" {
+			return legacyKey(t)
+		}
+		return signingKey(t)
+	})
+	if err == nil && t.Claims.(*JwtCustomClaims).Audience != "" {
+		return nil, errors.New("not an auth token")
+	}
+	return t, err
+}
+
+// signingKey is the public half of the Key of Keys named in t
+func signingKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, found := Keys.Key(kid)
+	if !found {
+		return nil, errors.New("unknown key " + kid)
+	}
+	if t.Method != key.Method {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public(), nil
+}
+
+// signFor signs claims, which must name their audience, with the Key of
+// Keys signing now
+func signFor(claims audienced) (string, error) {
+	key, err := Keys.Signer(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return key.Sign(claims)
+}
+
+// parseFor reads a token signed with Keys for aud into claims
+func parseFor(token, aud string, claims audienced) error {
+	if _, err := jwt.ParseWithClaims(token, claims, signingKey); err != nil {
+		return err
+	}
+	if !claims.VerifyAudience(aud, true) {
+		return errors.New("token is not meant for " + aud)
+	}
+	return nil
+}
+
+// keysSince is when tokens were first signed with Keys
+var keysSince = model.KeysSince
+
+// legacyKey verifies tokens signed with JWT_SECRET before Keys existed.
+// Only tokens issued before keysSince, and so expiring within TokenLife of
+// it, are accepted, whoever holds the secret now.
+func legacyKey(t *jwt.Token) (interface{}, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if t.Method != jwt.SigningMethodHS256 || secret == "" {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	cutoff := keysSince()
+	claims, ok := t.Claims.(*JwtCustomClaims)
+	if !ok || cutoff.IsZero() || claims.ExpiresAt == 0 ||
+		time.Unix(claims.ExpiresAt, 0).After(cutoff.Add(keyring.TokenLife)) ||
+		(claims.IssuedAt != 0 && !time.Unix(claims.IssuedAt, 0).Before(cutoff)) {
+		return nil, errors.New("legacy tokens are no longer accepted")
+	}
+	return []byte(secret), nil
+}
+
+// parseAccessToken dresses a personal access token up as a JWT for the
+// User it acts for, so handlers need not tell them apart
+func parseAccessToken(auth string) (*jwt.Token, error) {
+	t, user, err := model.UseAccessToken(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &JwtCustomClaims{User: user.ID, Role: user.Role, MFA: t.MFA, Token: &t}
+	return &jwt.Token{Claims: claims, Valid: true}, nil
+}
+
+// routeScopes holds the scope a personal access token needs for each route
+// registered through requireScope, keyed by "METHOD path" like routeDocs
+var routeScopes = map[string]model.Scope{}
+
+// requireScope opens routes to personal access tokens holding scope.
+// Routes calls it as it registers them.
+func requireScope(scope model.Scope, routes ...*echo.Route) {
+	for _, r := range routes {
+		routeScopes[r.Method+" "+r.Path] = scope
+	}
+}
+
+// tokenScope stops personal access tokens from using routes outside their
+// scopes. Routes registered without requireScope are closed to tokens.
+func tokenScope(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		t := claimsOf(c).Token
+		if t == nil {
+			return next(c)
+		}
+
+		scope := routeScopes[c.Request().Method+" "+c.Path()]
+		if scope == "" {
+			return errs.New(http.StatusForbidden, errs.CodeInsufficientScope, "Personal access tokens cannot use this route. Log in instead.")
+		}
+		if !t.Has(scope) {
+			return errs.New(http.StatusForbidden, errs.CodeInsufficientScope, "This token needs the "+string(scope)+" scope.")
+		}
+		return next(c)
+	}
+}
+
+// GetAccessTokens handles the "/users/restricted/me/tokens" route.
+func GetAccessTokens(c echo.Context) error {
+	user := model.User{Base: model.Base{ID: claimsOf(c).User}}
+	tokens, err := user.ReadAccessTokens()
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, AccessTokensResponse{Response: ok(status), Tokens: tokens})
+}
+
+// CreateAccessToken handles the "/users/restricted/me/tokens/create" route.
+// The token is only ever shown in this response.
+func CreateAccessToken(c echo.Context) error {
+	claims := claimsOf(c)
+	r := tokenRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	scopes, seen := []string{}, map[model.Scope]bool{}
+	for _, s := range r.Scopes {
+		if s == model.ScopeAdmin && !RBAC.IsGranted(string(claims.Role), permissionUserOps, nil) {
+			return errs.Invalid(errs.Field{Name: "scopes", Code: errs.FieldInvalid, Message: "Only keepers may grant the admin scope."})
+		}
+		if !seen[s] {
+			scopes, seen[s] = append(scopes, string(s)), true
+		}
+	}
+
+	days := r.Days
+	if days == 0 {
+		days = tokenDays
+	}
+
+	t := model.AccessToken{
+		User:    claims.User,
+		Name:    r.Name,
+		Scopes:  scopes,
+		MFA:     claims.MFA,
+		Expires: time.Now().AddDate(0, 0, days).UTC(),
+	}
+	if err := t.Create(); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, AccessTokenResponse{Response: ok(status), AccessToken: t})
+}
+
+// DeleteAccessToken handles the "/users/restricted/me/tokens/:id/delete" route.
+func DeleteAccessToken(c echo.Context) error {
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	t := model.AccessToken{Base: model.Base{ID: id}, User: claimsOf(c).User}
+	if err := t.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ok(status))
+}
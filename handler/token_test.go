@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/keyring"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestTokenScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		token  *model.AccessToken
+		status int
+	}{
+		{name: "Login Test", path: "/lists", status: http.StatusOK},
+		{name: "Scoped Token Test", path: "/lists", token: &model.AccessToken{Scopes: []string{"lists:read"}}, status: http.StatusOK},
+		{name: "Unscoped Token Test", path: "/lists", token: &model.AccessToken{Scopes: []string{"posts:read"}}, status: http.StatusForbidden},
+		{name: "Closed Route Test", path: "/users/restricted/me/tokens", token: &model.AccessToken{Scopes: []string{"admin"}}, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = ErrorHandler
+			withClaims := func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set("user", &jwt.Token{Claims: &JwtCustomClaims{User: uuid.NewV4(), Token: tt.token}})
+					return next(c)
+				}
+			}
+			route := e.GET(tt.path, func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, withClaims, tokenScope)
+			if tt.path == "/lists" {
+				requireScope(model.ScopeListsRead, route)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("tokenScope() status = %v, want %v", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+// useKeysSince sets when tokens were first signed with Keys, and returns a
+// func that puts it back
+func useKeysSince(at time.Time) func() {
+	previous := keysSince
+	keysSince = func() time.Time { return at }
+	return func() { keysSince = previous }
+}
+
+func TestParseAuth(t *testing.T) {
+	os.Setenv("JWT_SECRET", "secret")
+	defer useKeysSince(time.Now())()
+	useTestKeys(t)
+	user := model.User{Base: model.Base{ID: uuid.NewV4()}, Role: model.UserEditor}
+	auth, err := signAuth(user, true, uuid.NewV4(), time.Now())
+	if err != nil {
+		t.Fatalf("signAuth() error = %v", err)
+	}
+
+	got, err := parseAuth(auth, nil)
+	if err != nil {
+		t.Fatalf("parseAuth() error = %v", err)
+	}
+	claims := got.(*jwt.Token).Claims.(*JwtCustomClaims)
+	if claims.User != user.ID || claims.Role != user.Role || !claims.MFA || claims.Token != nil {
+		t.Errorf("parseAuth() claims = %+v", claims)
+	}
+
+	legacy := &JwtCustomClaims{User: user.ID, StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		key     interface{}
+		wantErr bool
+	}{
+		{name: "Legacy Secret Test", method: jwt.SigningMethodHS256, key: []byte("secret")},
+		{name: "Other Secret Test", method: jwt.SigningMethodHS256, key: []byte("other"), wantErr: true},
+		{name: "Unsigned Test", method: jwt.SigningMethodNone, key: jwt.UnsafeAllowNoneSignatureType, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _ := jwt.NewWithClaims(tt.method, legacy).SignedString(tt.key)
+			if _, err := parseAuth(token, nil); (err != nil) != tt.wantErr {
+				t.Errorf("parseAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, legacy)
+	forged.Header["kid"] = Keys.Keys()[0].ID
+	token, _ := forged.SignedString([]byte("secret"))
+	if _, err := parseAuth(token, nil); err == nil {
+		t.Error("parseAuth() accepted an HS256 token naming an EdDSA key")
+	}
+}
+
+func TestLegacyKeyAfterRotation(t *testing.T) {
+	os.Setenv("JWT_SECRET", "secret")
+	cutoff := time.Now().Add(-time.Hour)
+	defer useKeysSince(cutoff)()
+
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if Keys, err = keyring.Open(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(30 * 24 * time.Hour)
+	if _, err := keyring.Generate(dir, keyring.EdDSA, later); err != nil {
+		t.Fatal(err)
+	}
+	if retired, err := keyring.Retire(dir, keyring.TokenLife+keyring.PublishDelay, later.Add(keyring.TokenLife+keyring.PublishDelay+time.Minute)); err != nil || len(retired) != 1 {
+		t.Fatalf("Retire() = %v, %v, want the first key retired", retired, err)
+	}
+	if err := Keys.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	user := uuid.NewV4()
+	tests := []struct {
+		name    string
+		claims  jwt.StandardClaims
+		wantErr bool
+	}{
+		{name: "Issued Before Cutoff Test", claims: jwt.StandardClaims{ExpiresAt: cutoff.Add(keyring.TokenLife - time.Minute).Unix()}},
+		{name: "Minted After Cutoff Test", claims: jwt.StandardClaims{ExpiresAt: time.Now().Add(keyring.TokenLife).Unix()}, wantErr: true},
+		{name: "Issued After Cutoff Test", claims: jwt.StandardClaims{IssuedAt: time.Now().Unix(), ExpiresAt: time.Now().Add(time.Hour).Unix()}, wantErr: true},
+		{name: "Never Expiring Test", claims: jwt.StandardClaims{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &JwtCustomClaims{User: user, StandardClaims: tt.claims}
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+			if _, err := parseAuth(token, nil); (err != nil) != tt.wantErr {
+				t.Errorf("parseAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// GetTrashedPosts handles the "/trash/posts" route.
+// Keepers see every deleted post; everyone else sees their own.
+func GetTrashedPosts(c echo.Context) error {
+	claims := claimsOf(c)
+	creator := claims.User
+	if RBAC.IsGranted(string(claims.Role), permissionPostOps, nil) {
+		creator = uuid.Nil
+	}
+
+	posts, err := model.ReadTrashedPosts(creator)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, PostsResponse{Response: ok(status), Posts: posts})
+}
+
+// GetTrashedReactions handles the "/trash/reactions" route.
+// Keepers see every deleted reaction; everyone else sees their own.
+func GetTrashedReactions(c echo.Context) error {
+	claims := claimsOf(c)
+	user := claims.User
+	if RBAC.IsGranted(string(claims.Role), permissionPostOps, nil) {
+		user = uuid.Nil
+	}
+
+	reactions, err := model.ReadTrashedReactions(user)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, ReactionsResponse{Response: ok(status), Reactions: reactions})
+}
+
+// GetTrashedUsers handles the "/trash/users" route.
+func GetTrashedUsers(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionUserOps, nil) {
+		return errs.Forbidden()
+	}
+
+	users, err := model.ReadTrashedUsers()
+	if err != nil {
+		return err
+	}
+
+	return usersJSON(c, users)
+}
+
+// RestoreTrashedPost handles the "/trash/posts/:id/restore" route.
+func RestoreTrashedPost(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekTrashedPost(id)
+	if err != nil {
+		return err
+	}
+
+	if !uuid.Equal(post.Meta().Creator, claims.User) && !canEditPost(post.Meta(), claims) {
+		return errs.Forbidden()
+	}
+
+	if err := model.RestorePost(post, actorOf(c)); err != nil {
+		return err
+	}
+
+	post.Meta().DeletedAt = nil
+	event.Publish(event.New(event.PostRestored, claims.User, post))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: post})
+}
+
+// RestoreTrashedReaction handles the "/trash/reactions/:id/restore" route.
+func RestoreTrashedReaction(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	reaction := model.Reaction{Base: model.Base{ID: id}}
+	if err := reaction.ReadTrashed(); err != nil {
+		return err
+	}
+
+	if !uuid.Equal(reaction.User, claims.User) && !RBAC.IsGranted(string(claims.Role), permissionPostOps, nil) {
+		return errs.Forbidden()
+	}
+
+	if err := reaction.Restore(actorOf(c)); err != nil {
+		return err
+	}
+
+	reaction.DeletedAt = nil
+	event.Publish(event.New(event.ReactionRestored, claims.User, reaction))
+
+	status := http.StatusAccepted
+	return c.JSON(status, ReactionResponse{Response: ok(status), Reaction: reaction})
+}
+
+// RestoreTrashedUser handles the "/trash/users/:id/restore" route.
+// The user's posts and reactions deleted along with them come back too.
+func RestoreTrashedUser(c echo.Context) error {
+	claims := claimsOf(c)
+	if !RBAC.IsGranted(string(claims.Role), permissionUserOps, nil) {
+		return errs.Forbidden()
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.ReadTrashed(); err != nil {
+		return err
+	}
+
+	if err := user.Restore(actorOf(c)); err != nil {
+		return err
+	}
+
+	event.Publish(event.New(event.UserRestored, claims.User, user.ID))
+	user.Pass, user.DeletedAt = "", nil
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/mfa"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// challengeLife is how long a two-factor challenge can be answered
+const challengeLife = 5 * time.Minute
+
+// totpCode matches codes from an authenticator app, as opposed to recovery codes
+var totpCode = regexp.MustCompile(`^[0-9]{6}$`)
+
+// Enrollment is a TOTP secret to add to an authenticator app
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Challenge is answered with a second factor to finish logging in
+type Challenge struct {
+	Token     string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EnrollmentResponse is a response containing an Enrollment
+type EnrollmentResponse struct {
+	Response
+	Enrollment `json:"data"`
+}
+
+// ChallengeResponse is a response containing a Challenge
+type ChallengeResponse struct {
+	Response
+	Challenge `json:"data"`
+}
+
+// RecoveryCodesResponse is a response containing new recovery codes
+type RecoveryCodesResponse struct {
+	Response
+	Codes []string `json:"data"`
+}
+
+// TwoFactorPolicyResponse is a response containing the roles that must use two-factor authentication
+type TwoFactorPolicyResponse struct {
+	Response
+	Roles []model.UserRole `json:"data"`
+}
+
+// sendChallenge responds to a correct password from a User with two-factor authentication
+func sendChallenge(c echo.Context, user model.User) error {
+	ch := Challenge{ExpiresAt: time.Now().Add(challengeLife).UTC()}
+	claims := &jwt.StandardClaims{Subject: user.ID.String(), Audience: audChallenge, ExpiresAt: ch.ExpiresAt.Unix()}
+
+	var err error
+	if ch.Token, err = signFor(claims); err != nil {
+		return errs.Internal(err)
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, ChallengeResponse{Response: ok(status), Challenge: ch})
+}
+
+// readChallenge returns the User a challenge was sent to
+func readChallenge(token string) (uuid.UUID, error) {
+	claims := jwt.StandardClaims{}
+	if err := parseFor(token, audChallenge, &claims); err != nil {
+		return uuid.Nil, errs.Wrap(err, http.StatusUnauthorized, errs.CodeUnauthorized, "The challenge is invalid or has expired. Log in again.")
+	}
+	return uuid.FromStringOrNil(claims.Subject), nil
+}
+
+// checkSecondFactor checks a TOTP or recovery code from user, spending it
+// if it is right and locking user out after repeated failures
+func checkSecondFactor(c echo.Context, user *model.User, code string) error {
+	key, now := "2fa:"+user.ID.String(), time.Now()
+	if left := loginLockout.Locked(LimitStore, key, now); left > 0 {
+		return tooMany(c, left, errs.CodeLockedOut, "Too many wrong codes. Try again later.")
+	}
+
+	var right bool
+	var err error
+	if totpCode.MatchString(code) {
+		if step, ok := mfa.Verify(user.TOTP, code, now); ok {
+			right, err = user.UseTOTPStep(step)
+		}
+	} else {
+		right, err = user.UseRecoveryCode(mfa.HashRecoveryCode(code))
+	}
+	if err != nil {
+		return err
+	}
+
+	if !right {
+		loginLockout.Fail(LimitStore, key, now)
+		return errs.New(http.StatusUnauthorized, errs.CodeBadCredentials, "The code is incorrect or was already used.").
+			WithField("code", errs.FieldInvalid, "")
+	}
+
+	LimitStore.Clear(key)
+	return nil
+}
+
+// newRecoveryCodes makes recovery codes to show once, and the hashes to keep
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := mfa.NewRecoveryCodes()
+	if err != nil {
+		return nil, nil, errs.Internal(err)
+	}
+
+	hashes := []string{}
+	for _, code := range codes {
+		hashes = append(hashes, mfa.HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// readSelf reads the authenticated User
+func readSelf(c echo.Context) (model.User, error) {
+	user := model.User{Base: model.Base{ID: claimsOf(c).User}}
+	return user, user.Read()
+}
+
+// VerifyTwoFactor handles the "/users/auth/2fa" route.
+// It answers a Challenge from AuthUser with a TOTP or recovery code.
+func VerifyTwoFactor(c echo.Context) error {
+	r := challengeRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	id, err := readChallenge(r.Challenge)
+	if err != nil {
+		return err
+	}
+
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); err != nil {
+		return errs.Wrap(err, http.StatusUnauthorized, errs.CodeUnauthorized, "")
+	}
+
+	if !user.TwoFactor {
+		return errs.New(http.StatusConflict, errs.CodeConflict, "Two-factor authentication is not enabled. Log in again.")
+	}
+
+	if err := checkSecondFactor(c, &user, strings.TrimSpace(r.Code)); err != nil {
+		return err
+	}
+
+	user.Pass = ""
+	authString, err := createAuthString(c, user, true)
+	if err != nil {
+		return err
+	}
+
+	user.Auth = authString
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
+}
+
+// EnrollTwoFactor handles the "/users/restricted/me/2fa/enroll" route.
+// The secret is pending until EnableTwoFactor confirms a code from it.
+func EnrollTwoFactor(c echo.Context) error {
+	user, err := readSelf(c)
+	if err != nil {
+		return err
+	}
+
+	secret, err := mfa.NewSecret()
+	if err != nil {
+		return errs.Internal(err)
+	}
+
+	if err := user.EnrollTOTP(secret); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, EnrollmentResponse{Response: ok(status), Enrollment: Enrollment{Secret: secret, URI: mfa.URI("Yap", user.Mail, secret)}})
+}
+
+// EnableTwoFactor handles the "/users/restricted/me/2fa/enable" route.
+// It returns recovery codes, which are never shown again.
+func EnableTwoFactor(c echo.Context) error {
+	r := codeRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	user, err := readSelf(c)
+	if err != nil {
+		return err
+	}
+
+	if user.TwoFactor {
+		return c.NoContent(http.StatusNotModified)
+	}
+	if user.TOTP == "" {
+		return errs.New(http.StatusConflict, errs.CodeConflict, "Enroll before enabling two-factor authentication.")
+	}
+
+	step, right := mfa.Verify(user.TOTP, strings.TrimSpace(r.Code), time.Now())
+	if !right {
+		return errs.Invalid(errs.Field{Name: "code", Code: errs.FieldInvalid, Message: "The code is incorrect."})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return err
+	}
+	if err := user.EnableTOTP(step, hashes); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, RecoveryCodesResponse{Response: ok(status), Codes: codes})
+}
+
+// DisableTwoFactor handles the "/users/restricted/me/2fa/disable" route.
+func DisableTwoFactor(c echo.Context) error {
+	r := codeRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	user, err := readSelf(c)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactor {
+		return c.NoContent(http.StatusNotModified)
+	}
+	if model.TwoFactorRequired(user.Role) {
+		return errs.New(http.StatusConflict, errs.CodeTwoFactor, "Your role requires two-factor authentication.")
+	}
+
+	if err := checkSecondFactor(c, &user, strings.TrimSpace(r.Code)); err != nil {
+		return err
+	}
+
+	if err := user.DisableTOTP(actorOf(c)); err != nil {
+		return err
+	}
+
+	user.Pass = ""
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
+}
+
+// RenewRecoveryCodes handles the "/users/restricted/me/2fa/recovery" route.
+// It voids the caller's recovery codes and returns new ones.
+func RenewRecoveryCodes(c echo.Context) error {
+	r := codeRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	user, err := readSelf(c)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactor {
+		return errs.New(http.StatusConflict, errs.CodeConflict, "Two-factor authentication is not enabled.")
+	}
+
+	if err := checkSecondFactor(c, &user, strings.TrimSpace(r.Code)); err != nil {
+		return err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return err
+	}
+	if err := user.ReplaceRecoveryCodes(hashes); err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	return c.JSON(status, RecoveryCodesResponse{Response: ok(status), Codes: codes})
+}
+
+// GetTwoFactorPolicy handles the "/admin/2fa" route.
+func GetTwoFactorPolicy(c echo.Context) error {
+	status := http.StatusOK
+	return c.JSON(status, TwoFactorPolicyResponse{Response: ok(status), Roles: model.TwoFactorRoles()})
+}
+
+// UpdateTwoFactorPolicy handles the "/admin/2fa/update" route.
+// Only keepers may change which roles must use two-factor authentication.
+func UpdateTwoFactorPolicy(c echo.Context) error {
+	r := twoFactorPolicyRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionUserOps, nil) {
+		return errs.Forbidden()
+	}
+
+	if err := model.SetTwoFactorRoles(r.Roles, actorOf(c)); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, TwoFactorPolicyResponse{Response: ok(status), Roles: model.TwoFactorRoles()})
+}
+
+// twoFactorPolicy stops Users whose role requires two-factor authentication
+// from using a token issued without it, except to enroll
+func twoFactorPolicy(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims := claimsOf(c)
+		if !claims.MFA && model.TwoFactorRequired(claims.Role) && !strings.HasPrefix(c.Path(), "/users/restricted/me/2fa/") {
+			return errs.New(http.StatusForbidden, errs.CodeTwoFactor, "Your role requires two-factor authentication. Enable it, then log in again.")
+		}
+		return next(c)
+	}
+}
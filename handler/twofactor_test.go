@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestChallenge(t *testing.T) {
+	os.Unsetenv("JWT_SECRET")
+	useTestKeys(t)
+	user := model.User{Base: model.Base{ID: uuid.NewV4()}, Role: model.UserKeeper}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/users/auth", nil), rec)
+	if err := sendChallenge(c, user); err != nil {
+		t.Fatalf("sendChallenge() error = %v", err)
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("sendChallenge() status = %v, want %v", rec.Code, http.StatusAccepted)
+	}
+
+	r := ChallengeResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+		t.Fatalf("sendChallenge() sent %s: %v", rec.Body, err)
+	}
+
+	id, err := readChallenge(r.Token)
+	if err != nil || id != user.ID {
+		t.Errorf("readChallenge() = %v, %v, want %v", id, err, user.ID)
+	}
+
+	if _, err := parseAuth(r.Token, c); err == nil {
+		t.Error("a challenge passed for an auth token")
+	}
+
+	auth, err := signAuth(user, false, uuid.NewV4(), time.Now())
+	if err != nil {
+		t.Fatalf("signAuth() error = %v", err)
+	}
+	if _, err := readChallenge(auth); err == nil {
+		t.Error("an auth token passed for a challenge")
+	}
+
+	state, err := signFor(&oidcState{StandardClaims: jwt.StandardClaims{Subject: user.ID.String(), Audience: audOIDC}})
+	if err != nil {
+		t.Fatalf("signFor() error = %v", err)
+	}
+	if _, err := readChallenge(state); err == nil {
+		t.Error("an OIDC state passed for a challenge")
+	}
+}
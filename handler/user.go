@@ -3,7 +3,8 @@ package handler
 import (
 	"net/http"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
 	"github.com/l3njo/yap/model"
 	"github.com/labstack/echo/v4"
 	uuid "github.com/satori/go.uuid"
@@ -21,191 +22,196 @@ type UsersResponse struct {
 	Users []model.User `json:"data"`
 }
 
+// ProfileResponse is a response containing one Profile
+type ProfileResponse struct {
+	Response
+	model.Profile `json:"data"`
+}
+
+// ProfilesResponse is a response containing a slice of Profiles
+type ProfilesResponse struct {
+	Response
+	Profiles []model.Profile `json:"data"`
+}
+
 // GetUsers handles the "/users" route.
 func GetUsers(c echo.Context) error {
-	resp, status := UsersResponse{}, 0
-	users, status, err := model.ReadAllUsers()
+	users, err := model.ReadAllUsers()
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	for _, user := range users {
-		user.Pass = ""
-		resp.Users = append(resp.Users, user)
-	}
-
-	resp.Status, resp.Message = true, http.StatusText(status)
-	return c.JSON(status, resp)
+	return profilesJSON(c, users)
 }
 
 // GetUserByID handles the "/users/:id" route.
 func GetUserByID(c echo.Context) error {
-	resp, status := UserResponse{}, 0
 	user := model.User{}
-	user.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(user.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user.ID = id
+	if err := user.Read(); err != nil {
+		return err
 	}
 
-	status, err := user.Read()
+	return profileJSON(c, user)
+}
+
+// GetUserByHandle handles the "/users/by-handle/:handle" route.
+func GetUserByHandle(c echo.Context) error {
+	user, err := model.ReadUserByHandle(c.Param("handle"))
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	user.Pass = ""
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	return profileJSON(c, user)
+}
+
+// profileJSON writes the public Profile of user with their follow counts
+func profileJSON(c echo.Context, user model.User) error {
+	counts, err := model.CountFollows(user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.Follows = &counts
+	status := http.StatusOK
+	return c.JSON(status, ProfileResponse{Response: ok(status), Profile: user.Profile()})
 }
 
 // UpdateUser handles the "/users/me/update" route.
 func UpdateUser(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
-	resp, status := UserResponse{}, 0
-	user, u := model.User{}, model.User{}
-	if err := c.Bind(&u); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	user, r := model.User{}, userRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	user.ID = claims.User
-	if uuid.Equal(user.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := user.Read(); err != nil {
+		return err
 	}
 
-	if status, err := user.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user.Handle, user.Name, user.Mail = r.Handle, r.Name, r.Mail
+	if r.Life != nil {
+		user.Life = *r.Life
 	}
-
-	user.Name, user.Mail, user.Life = u.Name, u.Mail, u.Life
-	status, err := user.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if r.Avatar != nil {
+		user.Avatar = *r.Avatar
+	}
+	if r.Location != nil {
+		user.Location = *r.Location
+	}
+	if r.Links != nil {
+		user.Links = r.Links
+	}
+	if r.Hidden != nil {
+		user.Hidden = []string{}
+		for _, f := range r.Hidden {
+			user.Hidden = append(user.Hidden, string(f))
+		}
+	}
+	if err := user.Update(); err != nil {
+		return err
 	}
 
 	user.Pass = ""
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
 }
 
 // AssignUser handles the "/users/:id/assign" route.
 func AssignUser(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-	resp, status := UserResponse{}, 0
-	user, u := model.User{}, model.User{}
-	if err := c.Bind(&u); err != nil {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	claims := claimsOf(c)
+	user, r := model.User{}, assignRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
 	}
 
 	if !RBAC.IsGranted(string(claims.Role), permissionUserOps, nil) {
-		status := http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
-	if u.Role != model.UserKeeper &&
-		u.Role != model.UserEditor &&
-		u.Role != model.UserReader {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
-	}
-
-	user.ID = uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(user.ID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
 	}
 
-	if status, err := user.Read(); err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	user.ID = id
+	if err := user.Read(); err != nil {
+		return err
 	}
 
-	if u.Role != model.UserKeeper && user.Role == model.UserKeeper {
-		count, status, err := model.CountUsers(&model.User{Role: model.UserKeeper})
-		if err != nil {
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
-		}
-
-		if count == 1 {
-			status = http.StatusNotModified
-			resp.Message = "Only this keeper exists"
-			return c.JSON(status, resp)
+	if r.Role != model.UserKeeper && user.Role == model.UserKeeper {
+		if err := checkSoleKeeper(); err != nil {
+			return err
 		}
 	}
 
-	user.Role = u.Role
-	status, err := user.Update()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := user.Assign(r.Role, actorOf(c)); err != nil {
+		return err
 	}
 
 	user.Pass = ""
-	resp.Status, resp.Message, resp.User = true, http.StatusText(status), user
-	return c.JSON(status, resp)
+	event.Publish(event.New(event.UserAssigned, claims.User, model.Assignment{User: user.ID, Role: user.Role}))
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status), User: user})
 }
 
 // DeleteUser handles the "/users/:id/delete" route.
 func DeleteUser(c echo.Context) error {
-	userToken := c.Get("user").(*jwt.Token)
-	claims := userToken.Claims.(*JwtCustomClaims)
-
+	claims := claimsOf(c)
 	userID := uuid.FromStringOrNil(c.Param("id"))
-	resp, status := UserResponse{}, 0
-
 	if !RBAC.IsGranted(string(claims.Role), permissionUserOps, nil) && !uuid.Equal(claims.User, userID) {
-		status := http.StatusForbidden
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return errs.Forbidden()
 	}
 
 	user := model.User{
 		Base: model.Base{ID: userID},
 	}
 
-	status, err := user.Read()
-	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	if err := user.Read(); err != nil {
+		return err
 	}
 
 	if user.Role == model.UserKeeper {
-		count, status, err := model.CountUsers(&model.User{Role: model.UserKeeper})
-		if err != nil {
-			resp.Message = http.StatusText(status)
-			return c.JSON(status, resp)
+		if err := checkSoleKeeper(); err != nil {
+			return err
 		}
+	}
 
-		if count == 1 {
-			status = http.StatusNotModified
-			resp.Message = "Sole keeper"
-			return c.JSON(status, resp)
-		}
+	if err := user.Delete(actorOf(c)); err != nil {
+		return err
 	}
 
-	status, err = user.Delete()
+	event.Publish(event.New(event.UserDeleted, claims.User, user.ID))
+	status := http.StatusAccepted
+	return c.JSON(status, UserResponse{Response: ok(status)})
+}
+
+// checkSoleKeeper fails when only one keeper is left
+func checkSoleKeeper() error {
+	count, err := model.CountUsers(&model.User{Role: model.UserKeeper})
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	resp.Status, resp.Message = true, http.StatusText(status)
-	return c.JSON(status, resp)
+	if count == 1 {
+		return errs.New(http.StatusConflict, errs.CodeSoleKeeper, "Only this keeper exists.")
+	}
+	return nil
+}
+
+// checkUserExists fails when no User has the id given in field
+func checkUserExists(id uuid.UUID, field string) error {
+	user := model.User{Base: model.Base{ID: id}}
+	if err := user.Read(); errs.From(err).Status == http.StatusNotFound {
+		return errs.Invalid(errs.Field{Name: field, Code: errs.FieldInvalid, Message: "No such user."})
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
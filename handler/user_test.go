@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/l3njo/yap/model"
+)
+
+func TestProfile(t *testing.T) {
+	user := model.User{
+		Handle:   "ada",
+		Name:     "Ada",
+		Mail:     "ada@example.com",
+		Pass:     "hash",
+		Role:     model.UserKeeper,
+		Life:     "Wrote the first program.",
+		Avatar:   "https://example.com/ada.png",
+		Links:    []string{"https://ada.dev"},
+		Location: "London",
+		Hidden:   []string{string(model.ProfileLocation), string(model.ProfileLinks)},
+	}
+
+	b, err := json.Marshal(user.Profile())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	got := string(b)
+	for _, want := range []string{`"handle":"ada"`, `"life":"Wrote the first program."`, `"avatar":"https://example.com/ada.png"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Profile() = %s, want %s", got, want)
+		}
+	}
+	for _, leak := range []string{"mail", "ada@example.com", "hash", "keeper", "London", "ada.dev"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("Profile() = %s, leaks %s", got, leak)
+		}
+	}
+}
@@ -11,72 +11,60 @@ import (
 
 // GetUserPublicArticles handles the "/users/:id/posts/articles" route.
 func GetUserPublicArticles(c echo.Context) error {
-	resp, status := ArticlesResponse{}, 0
-	articles, status, err := model.ReadAllArticles()
+	userID, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	userID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(userID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	articles, err := model.ReadAllArticles()
+	if err != nil {
+		return err
 	}
 
 	articles = util.FilterA(articles, func(a model.Article) bool {
 		return (uuid.Equal(a.Creator, userID)) && a.Release
 	})
 
-	resp.Status, resp.Message, resp.Articles = true, http.StatusText(status), articles
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, ArticlesResponse{Response: ok(status), Articles: articles})
 }
 
 // GetUserPublicGalleries handles the "/users/:id/posts/galleries" route.
 func GetUserPublicGalleries(c echo.Context) error {
-	resp, status := GalleriesResponse{}, 0
-	galleries, status, err := model.ReadAllGalleries()
+	userID, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	userID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(userID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	galleries, err := model.ReadAllGalleries()
+	if err != nil {
+		return err
 	}
 
 	galleries = util.FilterG(galleries, func(g model.Gallery) bool {
 		return (uuid.Equal(g.Creator, userID)) && g.Release
 	})
 
-	resp.Status, resp.Message, resp.Galleries = true, http.StatusText(status), galleries
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, GalleriesResponse{Response: ok(status), Galleries: galleries})
 }
 
 // GetUserPublicFlickers handles the "/users/:id/posts/flickers" route.
 func GetUserPublicFlickers(c echo.Context) error {
-	resp, status := FlickersResponse{}, 0
-	flickers, status, err := model.ReadAllFlickers()
+	userID, err := paramID(c, "id")
 	if err != nil {
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+		return err
 	}
 
-	userID := uuid.FromStringOrNil(c.Param("id"))
-	if uuid.Equal(userID, uuid.Nil) {
-		status = http.StatusBadRequest
-		resp.Message = http.StatusText(status)
-		return c.JSON(status, resp)
+	flickers, err := model.ReadAllFlickers()
+	if err != nil {
+		return err
 	}
 
 	flickers = util.FilterF(flickers, func(f model.Flicker) bool {
 		return (uuid.Equal(f.Creator, userID)) && f.Release
 	})
 
-	resp.Status, resp.Message, resp.Flickers = true, http.StatusText(status), flickers
-	return c.JSON(status, resp)
+	status := http.StatusOK
+	return c.JSON(status, FlickersResponse{Response: ok(status), Flickers: flickers})
 }
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/marker"
+)
+
+// handlePattern is what a handle may look like
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// MediaHosts are the hosts uploaded media is served from.
+// yap takes no uploads itself; when MediaHosts is empty, media may be anywhere.
+var MediaHosts []string
+
+// Validator checks request DTOs against their "validate" tags.
+type Validator struct {
+	validate *validator.Validate
+}
+
+// NewValidator builds the Validator used by echo.
+func NewValidator() *Validator {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = v.RegisterValidation("password", validatePassword)
+	_ = v.RegisterValidation("event", validateEvent)
+	_ = v.RegisterValidation("handle", validateHandle)
+	_ = v.RegisterValidation("media", validateMedia)
+	_ = v.RegisterValidation("marker", validateMarker)
+
+	return &Validator{validate: v}
+}
+
+// Validate implements echo.Validator.
+func (v *Validator) Validate(i interface{}) error {
+	err := v.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	invalid, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return errs.Internal(err)
+	}
+
+	e := errs.Invalid()
+	for _, fe := range invalid {
+		e.WithField(fe.Field(), fe.Tag(), fieldMessage(fe))
+	}
+	return e
+}
+
+// validatePassword requires 8 to 72 bytes with at least one letter and one digit.
+// bcrypt ignores anything past 72 bytes.
+func validatePassword(fl validator.FieldLevel) bool {
+	pass := fl.Field().String()
+	if len(pass) < 8 || len(pass) > 72 {
+		return false
+	}
+
+	letter, digit := false, false
+	for _, r := range pass {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+// validateEvent accepts an event type name, or "*" for every event.
+func validateEvent(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	return name == "*" || event.Type(name).Public()
+}
+
+// validateHandle accepts 3 to 30 letters, digits or underscores.
+// Handles are stored lowercased.
+func validateHandle(fl validator.FieldLevel) bool {
+	return handlePattern.MatchString(fl.Field().String())
+}
+
+// validateMarker accepts up to marker.MaxLength characters.
+func validateMarker(fl validator.FieldLevel) bool {
+	return utf8.RuneCountInString(fl.Field().String()) <= marker.MaxLength
+}
+
+// validateMedia accepts an http or https URL on one of the MediaHosts,
+// or nothing, so the field can be cleared.
+func validateMedia(fl validator.FieldLevel) bool {
+	if fl.Field().String() == "" {
+		return true
+	}
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	if len(MediaHosts) == 0 {
+		return true
+	}
+	for _, h := range MediaHosts {
+		if strings.EqualFold(u.Hostname(), h) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldMessage describes a failed validation rule in words.
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if":
+		return "This field is required."
+	case "email":
+		return "Must be a valid mail address."
+	case "url":
+		return "Must be a valid URL."
+	case "media":
+		return "Must be the URL of uploaded media."
+	case "password":
+		return "Must be 8 to 72 characters with at least one letter and one digit."
+	case "marker":
+		return "Must be at most " + strconv.Itoa(marker.MaxLength) + " characters."
+	case "handle":
+		return "Must be 3 to 30 letters, digits or underscores."
+	case "event":
+		return "Must be an event type or \"*\"."
+	case "oneof":
+		return "Must be one of: " + fe.Param() + "."
+	case "max":
+		switch {
+		case fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array:
+			return "Must have at most " + fe.Param() + " items."
+		case numeric(fe.Kind()):
+			return "Must be at most " + fe.Param() + "."
+		}
+		return "Must be at most " + fe.Param() + " characters."
+	case "min":
+		switch {
+		case fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array:
+			return "Must have at least " + fe.Param() + " items."
+		case numeric(fe.Kind()):
+			return "Must be at least " + fe.Param() + "."
+		}
+		return "Must be at least " + fe.Param() + " characters."
+	}
+	return "Is invalid."
+}
+
+// numeric reports whether k is a kind of number, whose min and max are values
+// rather than lengths
+func numeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/marker"
+	"github.com/l3njo/yap/model"
+)
+
+// text points at s, for optional request fields
+func text(s string) *string {
+	return &s
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name       string
+		req        interface{}
+		wantFields []string
+	}{
+		{
+			name: "Valid Join Test",
+			req:  &joinRequest{Name: "Ada", Mail: "ada@example.com", Pass: "engine42"},
+		},
+		{
+			name:       "Invalid Join Test",
+			req:        &joinRequest{Mail: "not-a-mail", Pass: "short"},
+			wantFields: []string{"name", "mail", "pass"},
+		},
+		{
+			name:       "Weak Password Test",
+			req:        &passRequest{Current: "engine42", Updated: "onlyletters"},
+			wantFields: []string{"updated"},
+		},
+		{
+			name: "Invalid Flicker Test",
+			req: &flickerRequest{
+				postRequest: postRequest{Subject: "Clip", Overlay: "nope", Markers: make([]string, 11)},
+				Content:     "not a url",
+			},
+			wantFields: []string{"overlay", "markers", "content"},
+		},
+		{
+			name: "Long Marker Test",
+			req:  &postRequest{Subject: "Notes", Markers: []string{strings.Repeat("é", marker.MaxLength)}},
+		},
+		{
+			name:       "Too Long Marker Test",
+			req:        &markerMergeRequest{From: []string{"go"}, To: strings.Repeat("a", marker.MaxLength+1)},
+			wantFields: []string{"to"},
+		},
+		{
+			name:       "Update Without Version Test",
+			req:        &articleUpdateRequest{Content: "Edited"},
+			wantFields: []string{"version"},
+		},
+		{
+			name:       "Comment Without Text Test",
+			req:        &reactionRequest{Type: "comment"},
+			wantFields: []string{"text"},
+		},
+		{
+			name: "Approve Without Text Test",
+			req:  &reactionRequest{Type: "approve"},
+		},
+		{
+			name:       "Follow User By Body Test",
+			req:        &followRequest{Kind: model.FollowUser},
+			wantFields: []string{"kind", "target"},
+		},
+		{
+			name: "Valid Preferences Test",
+			req:  &preferencesRequest{Preferences: map[model.NotificationKind]bool{model.NotifyComment: false}},
+		},
+		{
+			name:       "Unknown Preference Test",
+			req:        &preferencesRequest{Preferences: map[model.NotificationKind]bool{"spam": true}},
+			wantFields: []string{"preferences[spam]"},
+		},
+		{
+			name: "Valid Profile Test",
+			req:  &userRequest{Handle: "Ada_L", Avatar: text("https://example.com/ada.png"), Links: []string{"https://ada.dev"}, Hidden: []model.ProfileField{model.ProfileLocation}},
+		},
+		{
+			name: "Cleared Profile Test",
+			req:  &userRequest{Life: text(""), Avatar: text(""), Location: text(""), Links: []string{}},
+		},
+		{
+			name:       "Invalid Profile Test",
+			req:        &userRequest{Handle: "ada lovelace", Avatar: text("ada.png"), Links: []string{"ada.dev"}, Hidden: []model.ProfileField{"mail"}},
+			wantFields: []string{"handle", "avatar", "links[0]", "hidden[0]"},
+		},
+		{
+			name:       "Script Avatar Test",
+			req:        &userRequest{Avatar: text("javascript://example.com/%0Aalert(1)")},
+			wantFields: []string{"avatar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.req)
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			e, ok := err.(*errs.Error)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *errs.Error", err)
+			}
+			got := map[string]bool{}
+			for _, f := range e.Fields {
+				got[f.Name] = true
+			}
+			for _, name := range tt.wantFields {
+				if !got[name] {
+					t.Errorf("Validate() fields = %v, want %v", e.Fields, name)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMedia(t *testing.T) {
+	v := NewValidator()
+	defer func(hosts []string) { MediaHosts = hosts }(MediaHosts)
+	MediaHosts = []string{"media.example.com"}
+
+	if err := v.Validate(&userRequest{Avatar: text("https://MEDIA.example.com/ada.png")}); err != nil {
+		t.Errorf("Validate() of a media host avatar error = %v, want nil", err)
+	}
+	if err := v.Validate(&userRequest{Avatar: text("https://example.com/ada.png")}); err == nil {
+		t.Errorf("Validate() of an avatar elsewhere error = nil, want invalid")
+	}
+}
+
+func TestFieldMessage(t *testing.T) {
+	v := NewValidator()
+	tests := []struct {
+		name  string
+		req   interface{}
+		field string
+		want  string
+	}{
+		{name: "Too Many Days Test", req: &tokenRequest{Name: "CI", Scopes: []model.Scope{model.ScopePostsRead}, Days: 400}, field: "days", want: "Must be at most 365."},
+		{name: "Negative Version Test", req: &postUpdateRequest{Version: -1}, field: "version", want: "Must be at least 1."},
+		{name: "Long Name Test", req: &tokenRequest{Name: strings.Repeat("a", 101), Scopes: []model.Scope{model.ScopePostsRead}}, field: "name", want: "Must be at most 100 characters."},
+		{name: "No Scopes Test", req: &tokenRequest{Name: "CI", Scopes: []model.Scope{}}, field: "scopes", want: "Must have at least 1 items."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.req)
+			e, ok := err.(*errs.Error)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *errs.Error", err)
+			}
+			for _, f := range e.Fields {
+				if f.Name == tt.field {
+					if f.Message != tt.want {
+						t.Errorf("Validate() %s message = %q, want %q", f.Name, f.Message, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("Validate() fields = %v, want %v", e.Fields, tt.field)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+)
+
+// WebhookResponse is a response containing one Webhook
+type WebhookResponse struct {
+	Response
+	model.Webhook `json:"data"`
+}
+
+// WebhooksResponse is a response containing a slice of Webhooks
+type WebhooksResponse struct {
+	Response
+	Webhooks []model.Webhook `json:"data"`
+}
+
+// DeliveriesResponse is a response containing a slice of WebhookDeliveries
+type DeliveriesResponse struct {
+	Response
+	Deliveries []model.WebhookDelivery `json:"data"`
+}
+
+// newSecret makes a random Webhook signing secret
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errs.Internal(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// readWebhook checks hookOps and loads the Webhook addressed by the ":id" path parameter
+func readWebhook(c echo.Context) (model.Webhook, error) {
+	hook := model.Webhook{}
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionHookOps, nil) {
+		return hook, errs.Forbidden()
+	}
+
+	id, err := paramID(c, "id")
+	if err != nil {
+		return hook, err
+	}
+
+	hook.ID = id
+	return hook, hook.Read()
+}
+
+// GetWebhooks handles the "/hooks" route.
+func GetWebhooks(c echo.Context) error {
+	if !RBAC.IsGranted(string(claimsOf(c).Role), permissionHookOps, nil) {
+		return errs.Forbidden()
+	}
+
+	hooks, err := model.ReadAllWebhooks()
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, WebhooksResponse{Response: ok(status), Webhooks: hooks})
+}
+
+// GetWebhookByID handles the "/hooks/:id" route.
+func GetWebhookByID(c echo.Context) error {
+	hook, err := readWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, WebhookResponse{Response: ok(status), Webhook: hook})
+}
+
+// CreateWebhook handles the "/hooks/create" route.
+func CreateWebhook(c echo.Context) error {
+	claims := claimsOf(c)
+	r := webhookRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	if !RBAC.IsGranted(string(claims.Role), permissionHookOps, nil) {
+		return errs.Forbidden()
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+
+	hook := model.Webhook{URL: r.URL, Events: r.Events, Active: true, Secret: secret, Creator: claims.User}
+	if r.Active != nil {
+		hook.Active = *r.Active
+	}
+
+	if err := hook.Create(); err != nil {
+		return err
+	}
+
+	hook.Revealed = hook.Secret
+	status := http.StatusCreated
+	return c.JSON(status, WebhookResponse{Response: ok(status), Webhook: hook})
+}
+
+// UpdateWebhook handles the "/hooks/:id/update" route.
+func UpdateWebhook(c echo.Context) error {
+	r := webhookRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	hook, err := readWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	hook.URL, hook.Events = r.URL, r.Events
+	if r.Active != nil {
+		hook.Active = *r.Active
+	}
+
+	if err := hook.Update(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, WebhookResponse{Response: ok(status), Webhook: hook})
+}
+
+// DeleteWebhook handles the "/hooks/:id/delete" route.
+func DeleteWebhook(c echo.Context) error {
+	hook, err := readWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	if err := hook.Delete(); err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, WebhookResponse{Response: ok(status)})
+}
+
+// GetWebhookDeliveries handles the "/hooks/:id/deliveries" route.
+func GetWebhookDeliveries(c echo.Context) error {
+	hook, err := readWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := hook.Deliveries()
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, DeliveriesResponse{Response: ok(status), Deliveries: deliveries})
+}
+
+// RedeliverWebhook handles the "/hooks/:id/deliveries/:delivery/redeliver" route.
+func RedeliverWebhook(c echo.Context) error {
+	hook, err := readWebhook(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c, "delivery")
+	if err != nil {
+		return err
+	}
+
+	delivery := model.WebhookDelivery{Base: model.Base{ID: id}}
+	if err := delivery.Read(); err != nil {
+		return err
+	}
+
+	if !uuid.Equal(delivery.Hook, hook.ID) {
+		return errs.New(http.StatusNotFound, errs.CodeNotFound, "")
+	}
+
+	queued, err := delivery.Redeliver()
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusAccepted
+	return c.JSON(status, DeliveriesResponse{Response: ok(status), Deliveries: []model.WebhookDelivery{queued}})
+}
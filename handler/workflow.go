@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/workflow"
+	"github.com/labstack/echo/v4"
+)
+
+// HistoryResponse is a response containing the workflow history of a Post
+type HistoryResponse struct {
+	Response
+	History []model.PostTransition `json:"data"`
+}
+
+// canReview reports whether the holder of claims may review Posts
+func canReview(claims *JwtCustomClaims) bool {
+	return RBAC.IsGranted(string(claims.Role), permissionPostOps, nil)
+}
+
+// canReviewPost reports whether the holder of claims may take the review
+// action a on a Post. Reviewers may take any; those invited to the Post as
+// its reviewer may ask for changes.
+func canReviewPost(post *model.PostBase, a workflow.Action, claims *JwtCustomClaims) (bool, error) {
+	if canReview(claims) {
+		return true, nil
+	} else if a != workflow.RequestChanges {
+		return false, nil
+	}
+
+	role, err := model.AuthorRoleOf(post, claims.User)
+	return role == model.AuthorReviewer, err
+}
+
+// checkEditable fails when post waits on or has passed review and the holder
+// of claims may not review it, since their edit would skip the review
+func checkEditable(post *model.PostBase, claims *JwtCustomClaims) error {
+	if (post.State == workflow.InReview || post.State == workflow.Approved) && !canReview(claims) {
+		return errs.New(http.StatusUnprocessableEntity, errs.CodeNotEditable, "This post is "+string(post.State)+". It can be edited once a reviewer asks for changes.")
+	}
+	return nil
+}
+
+// transitionPost takes action a on the Post in the "id" param, and publishes
+// t once it is done. Review actions are checked by canReviewPost; anyone who
+// may write the Post may take the others.
+func transitionPost(c echo.Context, a workflow.Action, comment string, t event.Type, review bool) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(id)
+	if err != nil {
+		return err
+	}
+
+	if review {
+		if ok, err := canReviewPost(post.Meta(), a, claims); err != nil {
+			return err
+		} else if !ok {
+			return errs.Forbidden()
+		}
+	} else if ok, err := canWritePost(post.Meta(), claims); err != nil {
+		return err
+	} else if !ok {
+		return errs.Forbidden()
+	}
+
+	if _, ok := workflow.Next(post.Meta().State, a); !ok && post.Meta().State == workflow.Target(a) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	if _, err := model.TransitionPost(post, a, actorOf(c), comment); err != nil {
+		return err
+	}
+
+	event.Publish(event.New(t, claims.User, post))
+
+	status := http.StatusAccepted
+	return c.JSON(status, PostResponse{Response: ok(status), Post: post})
+}
+
+// SubmitPost handles the "/posts/:id/submit" route.
+func SubmitPost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Submit, r.Comment, event.PostSubmitted, false)
+}
+
+// ApprovePost handles the "/posts/:id/approve" route.
+func ApprovePost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Approve, r.Comment, event.PostApproved, true)
+}
+
+// RequestPostChanges handles the "/posts/:id/request-changes" route.
+func RequestPostChanges(c echo.Context) error {
+	r := changesRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.RequestChanges, r.Comment, event.PostChangesRequested, true)
+}
+
+// PublishPost handles the "/posts/:id/publish" route.
+// Only approved posts may be published.
+func PublishPost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Publish, r.Comment, event.PostPublished, true)
+}
+
+// RetractPost handles the "/posts/:id/retract" route.
+func RetractPost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Retract, r.Comment, event.PostRetracted, true)
+}
+
+// ArchivePost handles the "/posts/:id/archive" route.
+func ArchivePost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Archive, r.Comment, event.PostArchived, true)
+}
+
+// UnarchivePost handles the "/posts/:id/unarchive" route.
+func UnarchivePost(c echo.Context) error {
+	r := reviewRequest{}
+	if err := bind(c, &r); err != nil {
+		return err
+	}
+
+	return transitionPost(c, workflow.Unarchive, r.Comment, event.PostUnarchived, true)
+}
+
+// GetPostHistory handles the "/posts/:id/history" route.
+// It is open to reviewers and to anyone invited to work on the Post.
+func GetPostHistory(c echo.Context) error {
+	claims := claimsOf(c)
+	id, err := paramID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	post, err := model.PeekPost(id)
+	if err != nil {
+		return err
+	}
+
+	if !canReview(claims) {
+		role, err := model.AuthorRoleOf(post.Meta(), claims.User)
+		if err != nil {
+			return err
+		}
+		if role == "" {
+			return errs.Forbidden()
+		}
+	}
+
+	history, err := model.ReadPostHistory(id)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	return c.JSON(status, HistoryResponse{Response: ok(status), History: history})
+}
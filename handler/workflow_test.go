@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/workflow"
+)
+
+func TestCheckEditable(t *testing.T) {
+	if err := InitRBAC(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		state   workflow.State
+		role    model.UserRole
+		wantErr bool
+	}{
+		{name: "Draft Test", state: workflow.Draft, role: model.UserReader},
+		{name: "In Review Test", state: workflow.InReview, role: model.UserReader, wantErr: true},
+		{name: "Approved Test", state: workflow.Approved, role: model.UserReader, wantErr: true},
+		{name: "Reviewer Test", state: workflow.Approved, role: model.UserKeeper},
+		{name: "Published Test", state: workflow.Published, role: model.UserReader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEditable(&model.PostBase{State: tt.state}, &JwtCustomClaims{Role: tt.role})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkEditable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errs.From(err).Code != errs.CodeNotEditable {
+				t.Errorf("checkEditable() code = %v, want %v", errs.From(err).Code, errs.CodeNotEditable)
+			}
+		})
+	}
+}
+
+func TestCanReviewPost(t *testing.T) {
+	if err := InitRBAC(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		action workflow.Action
+		role   model.UserRole
+		want   bool
+	}{
+		{name: "Reviewer Approve Test", action: workflow.Approve, role: model.UserKeeper, want: true},
+		{name: "Reviewer Changes Test", action: workflow.RequestChanges, role: model.UserKeeper, want: true},
+		{name: "Reader Approve Test", action: workflow.Approve, role: model.UserReader},
+		{name: "Reader Publish Test", action: workflow.Publish, role: model.UserReader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canReviewPost(&model.PostBase{State: workflow.InReview}, tt.action, &JwtCustomClaims{Role: tt.role})
+			if err != nil || got != tt.want {
+				t.Errorf("canReviewPost() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package hook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+)
+
+// Retry policy for failed deliveries
+const (
+	MaxAttempts = 8
+	BaseDelay   = 30 * time.Second
+	MaxDelay    = 6 * time.Hour
+)
+
+// batchSize is how many due deliveries one poll sends
+const batchSize = 20
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Sign returns the signature of body for a Webhook secret,
+// sent in the X-Yap-Signature header as "sha256=<hex>".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns how long to wait after a delivery has failed attempts times
+func Backoff(attempts int) time.Duration {
+	delay := BaseDelay
+	for i := 1; i < attempts && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+	return delay
+}
+
+// Start queues deliveries for published events and sends due deliveries
+// every interval until the process exits.
+func Start(interval time.Duration) {
+	event.Subscribe(func(e event.Event) {
+		if err := model.QueueDeliveries(e); err != nil {
+			log.Println("hook: queue:", err)
+		}
+	})
+
+	go func() {
+		for range time.Tick(interval) {
+			Flush()
+		}
+	}()
+}
+
+// Flush sends every delivery that is currently due
+func Flush() {
+	deliveries, err := model.DueDeliveries(batchSize)
+	if err != nil {
+		log.Println("hook: poll:", err)
+		return
+	}
+
+	for i := range deliveries {
+		if err := Send(&deliveries[i]); err != nil {
+			log.Println("hook: record:", err)
+		}
+	}
+}
+
+// Send attempts one delivery and records the outcome
+func Send(d *model.WebhookDelivery) error {
+	hook := model.Webhook{Base: model.Base{ID: d.Hook}}
+	if err := hook.Read(); err != nil {
+		return d.Fail(0, "webhook no longer exists", nil)
+	}
+
+	code, err := post(&hook, d)
+	if err == nil {
+		return d.Succeed(code)
+	}
+
+	var retry *time.Time
+	if d.Attempts+1 < MaxAttempts && hook.Active {
+		next := time.Now().UTC().Add(Backoff(d.Attempts + 1))
+		retry = &next
+	}
+	return d.Fail(code, err.Error(), retry)
+}
+
+// post sends a delivery's payload to its Webhook
+func post(hook *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "yap-webhooks")
+	req.Header.Set("X-Yap-Event", string(d.Type))
+	req.Header.Set("X-Yap-Delivery", d.ID.String())
+	req.Header.Set("X-Yap-Signature", Sign(hook.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
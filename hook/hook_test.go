@@ -0,0 +1,51 @@
+package hook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			// echo -n '{"type":"post.published"}' | openssl dgst -sha256 -hmac secret
+			name:   "OpenSSL Reference Test",
+			secret: "secret",
+			body:   `{"type":"post.published"}`,
+			want:   "sha256=65ad21888e6d22abe8787e789e98a742be8db9c6322a28e4ea608e75fbb74b74",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{name: "First Retry Test", attempts: 1, want: 30 * time.Second},
+		{name: "Third Retry Test", attempts: 3, want: 2 * time.Minute},
+		{name: "Capped Retry Test", attempts: 20, want: MaxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.attempts); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// TokenLife is how long tokens signed with a Ring stay valid
+const TokenLife = 72 * time.Hour
+
+// PublishDelay is how long a new Key is only trusted before it signs,
+// so every server has reloaded it by the time tokens signed with it arrive
+const PublishDelay = 5 * time.Minute
+
+// Algorithms Keys can be generated for
+const (
+	EdDSA = "EdDSA"
+	RS256 = "RS256"
+)
+
+// createdHeader is the PEM header recording when a Key was made
+const createdHeader = "Created"
+
+// Key is a private key that signs tokens, named by its ID
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Created time.Time
+}
+
+// Ring holds the Keys in a directory, one PEM file per Key.
+// The newest published Key signs; all of them verify.
+type Ring struct {
+	dir  string
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+// JWK is the public half of a Key as a JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Open loads the Keys in dir, making dir and a first EdDSA Key if there are none
+func Open(dir string) (*Ring, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	r := &Ring{dir: dir}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if len(r.keys) == 0 {
+		if _, err := Generate(dir, EdDSA, time.Now()); err != nil {
+			return nil, err
+		}
+		return r, r.Reload()
+	}
+	return r, nil
+}
+
+// Start reloads r every interval, to pick up Keys rotated by other processes
+func (r *Ring) Start(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if err := r.Reload(); err != nil {
+				log.Println("keyring:", err)
+			}
+		}
+	}()
+}
+
+// Reload reads the Keys in r's directory again
+func (r *Ring) Reload() error {
+	keys, err := Load(r.dir)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.mu.Unlock()
+	return nil
+}
+
+// Signer is the Key that signs tokens at now: the newest one published
+// for PublishDelay, or the oldest if none has been
+func (r *Ring) Signer(now time.Time) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.keys) == 0 {
+		return nil, fmt.Errorf("keyring: no keys in %s", r.dir)
+	}
+
+	signer := r.keys[0]
+	for _, k := range r.keys {
+		if k.Created.Add(PublishDelay).After(now) {
+			break
+		}
+		signer = k
+	}
+	return signer, nil
+}
+
+// Key returns the Key with ID kid
+func (r *Ring) Key(kid string) (*Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// Keys lists the Keys in r, oldest first
+func (r *Ring) Keys() []*Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*Key{}, r.keys...)
+}
+
+// JWKS publishes the public halves of r's Keys
+func (r *Ring) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range r.Keys() {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	return set
+}
+
+// Sign signs claims with k, naming k in the token header
+func (k *Key) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.Method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.Private)
+}
+
+// Public is the key that verifies tokens signed with k
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// JWK is the public half of k as a JSON Web Key
+func (k *Key) JWK() JWK {
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.Public().(type) {
+	case ed25519.PublicKey:
+		j.Kty, j.Crv, j.X = "OKP", "Ed25519", base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		j.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	}
+	return j
+}
+
+// Load reads every Key in dir, oldest first
+func Load(dir string) ([]*Key, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*Key{}
+	for _, f := range files {
+		k, err := load(f)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %s: %v", f, err)
+		}
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Created.Equal(keys[j].Created) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].Created.Before(keys[j].Created)
+	})
+	return keys, nil
+}
+
+// load reads the Key in the PEM file f
+func load(f string) (*Key, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PKCS #8 private key")
+	}
+	created, err := time.Parse(time.RFC3339, block.Headers[createdHeader])
+	if err != nil {
+		return nil, fmt.Errorf("bad %s header: %v", createdHeader, err)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Key{ID: strings.TrimSuffix(filepath.Base(f), ".pem"), Created: created}
+	switch p := private.(type) {
+	case ed25519.PrivateKey:
+		k.Method, k.Private = jwt.SigningMethodEdDSA, p
+	case *rsa.PrivateKey:
+		k.Method, k.Private = jwt.SigningMethodRS256, p
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+	return k, nil
+}
+
+// Generate makes a Key for alg in dir, created at now
+func Generate(dir, alg string, now time.Time) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return nil, fmt.Errorf("keyring: unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now = now.UTC().Truncate(time.Second)
+	id := fmt.Sprintf("%s-%x", now.Format("20060102T150405Z"), suffix)
+
+	block := &pem.Block{Type: "PRIVATE KEY", Headers: map[string]string{createdHeader: now.Format(time.RFC3339)}, Bytes: der}
+	f, err := os.OpenFile(filepath.Join(dir, id+".pem"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := pem.Encode(f, block); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return load(filepath.Join(dir, id+".pem"))
+}
+
+// Retire deletes the Keys in dir that were replaced more than after ago,
+// returning their IDs. Nothing they signed can still be valid once after
+// is at least TokenLife + PublishDelay.
+func Retire(dir string, after time.Duration, now time.Time) ([]string, error) {
+	keys, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	retired := []string{}
+	for i := 0; i+1 < len(keys); i++ {
+		if now.Sub(keys[i+1].Created) <= after {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, keys[i].ID+".pem")); err != nil {
+			return retired, err
+		}
+		retired = append(retired, keys[i].ID)
+	}
+	return retired, nil
+}
@@ -0,0 +1,113 @@
+package keyring
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestOpen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	keys := r.Keys()
+	if len(keys) != 1 || keys[0].Method != jwt.SigningMethodEdDSA {
+		t.Fatalf("Open() made keys %v, want one EdDSA key", keys)
+	}
+
+	again, err := Open(dir)
+	if err != nil || len(again.Keys()) != 1 || again.Keys()[0].ID != keys[0].ID {
+		t.Errorf("Open() again = %v, %v, want the same key", again.Keys(), err)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	old, err := Generate(dir, RS256, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	fresh, err := Generate(dir, EdDSA, now)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{name: "Unpublished Key Test", at: now, want: old.ID},
+		{name: "Published Key Test", at: now.Add(PublishDelay + time.Second), want: fresh.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := r.Signer(tt.at)
+			if err != nil || k.ID != tt.want {
+				t.Errorf("Signer() = %v, %v, want %v", k, err, tt.want)
+			}
+		})
+	}
+
+	for _, k := range []*Key{old, fresh} {
+		token, err := k.Sign(jwt.StandardClaims{Subject: "ada"})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+			key, _ := r.Key(t.Header["kid"].(string))
+			return key.Public(), nil
+		})
+		if err != nil || !parsed.Valid {
+			t.Errorf("%s token did not verify: %v", k.Method.Alg(), err)
+		}
+	}
+
+	if set := r.JWKS(); len(set.Keys) != 2 || set.Keys[0].Kty != "RSA" || set.Keys[1].Kty != "OKP" {
+		t.Errorf("JWKS() = %+v", set)
+	}
+}
+
+func TestRetire(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	oldest, _ := Generate(dir, EdDSA, now.Add(-200*time.Hour))
+	Generate(dir, EdDSA, now.Add(-100*time.Hour))
+	Generate(dir, EdDSA, now.Add(-time.Hour))
+
+	retired, err := Retire(dir, TokenLife+PublishDelay, now)
+	if err != nil {
+		t.Fatalf("Retire() error = %v", err)
+	}
+	if len(retired) != 1 || retired[0] != oldest.ID {
+		t.Errorf("Retire() = %v, want [%v]", retired, oldest.ID)
+	}
+	if keys, _ := Load(dir); len(keys) != 2 {
+		t.Errorf("Retire() left %d keys, want 2", len(keys))
+	}
+}
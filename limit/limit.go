@@ -0,0 +1,177 @@
+package limit
+
+import (
+	"sync"
+	"time"
+)
+
+// Rate is a token bucket that holds up to Burst tokens and gains one every Every
+type Rate struct {
+	Burst int
+	Every time.Duration
+}
+
+// Store keeps token buckets and failure counts by key
+type Store interface {
+	// Take spends a token from the bucket at key. It returns zero if one was
+	// spent, or how long until one will be available if the bucket is empty.
+	Take(key string, r Rate, now time.Time) time.Duration
+	// Fail counts a failure at key and returns how many have been counted
+	// since the last Clear, forgetting those older than forget.
+	Fail(key string, now time.Time, forget time.Duration) int
+	// Failures returns how many failures are counted at key, and when the last one was
+	Failures(key string) (int, time.Time)
+	// Clear forgets the failures at key
+	Clear(key string)
+}
+
+// bucket is a token bucket and when it will be full again
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// failures is a run of failures and when the last one was
+type failures struct {
+	count  int
+	last   time.Time
+	forget time.Time
+}
+
+// sweepEvery is how often a MemoryStore drops buckets and failures it no longer needs
+const sweepEvery = time.Minute
+
+// MemoryStore is a Store held in memory, for a single process
+type MemoryStore struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	failures map[string]*failures
+	swept    time.Time
+}
+
+// NewMemoryStore makes an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, failures: map[string]*failures{}}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(key string, r Rate, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(r.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(r.Every)
+	if b.tokens > float64(r.Burst) {
+		b.tokens = float64(r.Burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(r.Every))
+	}
+	b.tokens--
+	b.full = now.Add(time.Duration((float64(r.Burst) - b.tokens) * float64(r.Every)))
+	return 0
+}
+
+// Fail implements Store
+func (s *MemoryStore) Fail(key string, now time.Time, forget time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	f, ok := s.failures[key]
+	if !ok || now.After(f.forget) {
+		f = &failures{}
+		s.failures[key] = f
+	}
+	f.count++
+	f.last, f.forget = now, now.Add(forget)
+	return f.count
+}
+
+// Failures implements Store
+func (s *MemoryStore) Failures(key string) (int, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.failures[key]; ok {
+		return f.count, f.last
+	}
+	return 0, time.Time{}
+}
+
+// Clear implements Store
+func (s *MemoryStore) Clear(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+}
+
+// sweep drops full buckets and forgotten failures. s.mu must be held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepEvery {
+		return
+	}
+	s.swept = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, f := range s.failures {
+		if now.After(f.forget) {
+			delete(s.failures, key)
+		}
+	}
+}
+
+// Lockout locks a key out for longer and longer after repeated failures
+type Lockout struct {
+	// Threshold is how many failures in a row are allowed before the first lock
+	Threshold int
+	// Base is how long the first lock lasts; each failure after it doubles the lock
+	Base time.Duration
+	// Max is the longest a lock lasts
+	Max time.Duration
+	// Forget is how long failures are remembered
+	Forget time.Duration
+}
+
+// Wait returns how long after the last failure counted at a key it stays locked
+func (l Lockout) Wait(count int) time.Duration {
+	if count < l.Threshold {
+		return 0
+	}
+
+	wait := l.Base
+	for i := l.Threshold; i < count && wait < l.Max; i++ {
+		wait *= 2
+	}
+	if wait > l.Max {
+		wait = l.Max
+	}
+	return wait
+}
+
+// Locked returns how much longer key is locked out of s, or zero if it is not
+func (l Lockout) Locked(s Store, key string, now time.Time) time.Duration {
+	count, last := s.Failures(key)
+	if left := last.Add(l.Wait(count)).Sub(now); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Fail counts a failure at key in s
+func (l Lockout) Fail(s Store, key string, now time.Time) {
+	s.Fail(key, now, l.Forget)
+}
@@ -0,0 +1,103 @@
+package limit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	s := NewMemoryStore()
+	r := Rate{Burst: 3, Every: 10 * time.Second}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < r.Burst; i++ {
+		if wait := s.Take("ip", r, now); wait != 0 {
+			t.Fatalf("Take() %d = %v, want 0", i, wait)
+		}
+	}
+
+	if wait := s.Take("ip", r, now); wait != 10*time.Second {
+		t.Errorf("Take() on empty bucket = %v, want 10s", wait)
+	}
+	if wait := s.Take("other", r, now); wait != 0 {
+		t.Errorf("Take() on other key = %v, want 0", wait)
+	}
+	if wait := s.Take("ip", r, now.Add(4*time.Second)); wait != 6*time.Second {
+		t.Errorf("Take() after 4s = %v, want 6s", wait)
+	}
+	if wait := s.Take("ip", r, now.Add(10*time.Second)); wait != 0 {
+		t.Errorf("Take() after refill = %v, want 0", wait)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	s := NewMemoryStore()
+	r := Rate{Burst: 2, Every: time.Second}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.Take("ip", r, now)
+	s.Fail("mail", now, time.Minute)
+	s.Take("other", r, now.Add(2*time.Minute))
+
+	if _, ok := s.buckets["ip"]; ok {
+		t.Error("sweep() kept a full bucket")
+	}
+	if _, ok := s.failures["mail"]; ok {
+		t.Error("sweep() kept forgotten failures")
+	}
+}
+
+func TestLockout(t *testing.T) {
+	l := Lockout{Threshold: 3, Base: time.Minute, Max: 10 * time.Minute, Forget: time.Hour}
+	s := NewMemoryStore()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 1; i < l.Threshold; i++ {
+		l.Fail(s, "mail", now)
+		if left := l.Locked(s, "mail", now); left != 0 {
+			t.Fatalf("Locked() after %d failures = %v, want 0", i, left)
+		}
+	}
+
+	l.Fail(s, "mail", now)
+	if left := l.Locked(s, "mail", now.Add(20*time.Second)); left != 40*time.Second {
+		t.Errorf("Locked() after threshold = %v, want 40s", left)
+	}
+
+	l.Fail(s, "mail", now.Add(time.Minute))
+	if left := l.Locked(s, "mail", now.Add(time.Minute)); left != 2*time.Minute {
+		t.Errorf("Locked() after one more failure = %v, want 2m", left)
+	}
+
+	s.Clear("mail")
+	if left := l.Locked(s, "mail", now.Add(time.Minute)); left != 0 {
+		t.Errorf("Locked() after Clear() = %v, want 0", left)
+	}
+
+	l.Fail(s, "mail", now)
+	if count := s.Fail("mail", now.Add(2*time.Hour), l.Forget); count != 1 {
+		t.Errorf("Fail() after Forget = %v, want 1", count)
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := Lockout{Threshold: 5, Base: time.Minute, Max: time.Hour}
+	tests := []struct {
+		count int
+		want  time.Duration
+	}{
+		{count: 0, want: 0},
+		{count: 4, want: 0},
+		{count: 5, want: time.Minute},
+		{count: 6, want: 2 * time.Minute},
+		{count: 10, want: 32 * time.Minute},
+		{count: 11, want: time.Hour},
+		{count: 500, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := l.Wait(tt.count); got != tt.want {
+			t.Errorf("Wait(%d) = %v, want %v", tt.count, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,22 @@ package main
 
 import (
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/l3njo/yap/db"
 	"github.com/l3njo/yap/handler"
+	"github.com/l3njo/yap/hook"
+	"github.com/l3njo/yap/keyring"
 	"github.com/l3njo/yap/model"
+	"github.com/l3njo/yap/notify"
+	"github.com/l3njo/yap/oidc"
+	"github.com/l3njo/yap/related"
+	"github.com/l3njo/yap/stream"
+	"github.com/l3njo/yap/trash"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -19,7 +27,7 @@ import (
 var (
 	e         *echo.Echo
 	port      string
-	jwtSecret []byte
+	publicURL string
 	signals   chan os.Signal
 )
 
@@ -41,8 +49,63 @@ func init() {
 	try(godotenv.Load())
 	try(model.InitDB(os.Getenv("DATABASE_URL")))
 	try(handler.InitRBAC())
-	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+	hook.Start(15 * time.Second)
+	notify.Start()
+	stream.Start()
+	related.Start()
+	retention, err := trash.ParseRetention(os.Getenv("TRASH_RETENTION"))
+	try(err)
+	trash.Start(retention)
+	try(initKeys())
+	handler.RedocBundle = os.Getenv("REDOC_BUNDLE")
+	handler.MediaHosts = strings.FieldsFunc(os.Getenv("MEDIA_HOSTS"), func(r rune) bool { return r == ',' || r == ' ' })
 	port = os.Getenv("PORT")
+	if publicURL = os.Getenv("PUBLIC_URL"); publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+	try(initOIDC())
+}
+
+// initKeys opens the keys that sign auth tokens, in the JWT_KEYS directory
+// or "keys". Rotate them with cmd/yapkeys; every server picks new ones up
+// within a minute.
+func initKeys() error {
+	dir := os.Getenv("JWT_KEYS")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	ring, err := keyring.Open(dir)
+	if err != nil {
+		return err
+	}
+	ring.Start(time.Minute)
+	handler.Keys = ring
+	return nil
+}
+
+// initOIDC registers the login providers in OIDC_PROVIDERS and, when
+// OIDC_LOCAL is "true", serves a stand-in provider named "local" for
+// development. It lets anyone log in as any mail.
+func initOIDC() error {
+	providers, err := oidc.Configure(os.Getenv, publicURL)
+	if err != nil {
+		return err
+	}
+	for _, p := range providers {
+		handler.Providers[p.Name] = p
+	}
+
+	if os.Getenv("OIDC_LOCAL") == "true" {
+		log.Println("Serving the stand-in OIDC provider at /oidc/local. Do not do this in production.")
+		local, err := oidc.NewLocal(publicURL+"/oidc/local", "yap")
+		if err != nil {
+			return err
+		}
+		e.Any("/oidc/local/*", echo.WrapHandler(local))
+		handler.Providers["local"] = local.Provider("local", publicURL)
+	}
+	return nil
 }
 
 /* TODO
@@ -53,10 +116,13 @@ func init() {
 */
 func main() {
 	jwtConfig := middleware.JWTConfig{
-		Claims:     &handler.JwtCustomClaims{},
-		SigningKey: jwtSecret,
+		Claims: &handler.JwtCustomClaims{},
 	}
 
+	extractor, err := handler.IPExtractor(os.Getenv("TRUSTED_PROXIES"))
+	try(err)
+	e.IPExtractor = extractor
+
 	e.Pre(middleware.RemoveTrailingSlash())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
@@ -65,102 +131,10 @@ func main() {
 		AllowMethods: []string{echo.GET, echo.HEAD, echo.PUT, echo.PATCH, echo.POST, echo.DELETE},
 	}))
 
-	// PATH /users
-	u := e.Group("/users")
-	u.GET("", handler.GetUsers)
-	u.GET("/:id", handler.GetUserByID)
-	u.POST("/join", handler.JoinUser)
-	u.POST("/auth", handler.AuthUser)
-	u.GET("/:id/posts/articles", handler.GetUserPublicArticles)
-	u.GET("/:id/posts/galleries", handler.GetUserPublicGalleries)
-	u.GET("/:id/posts/flickers", handler.GetUserPublicFlickers)
-	u.GET("/:id/reactions", handler.GetUserReactions)
-
-	// PATH /users/restricted
-	uAuth := u.Group("/restricted")
-	uAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	// uAuth.GET("/:id/posts/articles", handler.GetUserArticles) // TODO
-	// uAuth.GET("/:id/posts/galleries", handler.GetUserGalleries) // TODO
-	// uAuth.GET("/:id/posts/flickers", handler.GetUserFlickers) // TODO
-	uAuth.PUT("/me/update", handler.UpdateUser)
-	uAuth.PUT("/me/change", handler.UpdatePass)
-	uAuth.PUT("/:id/assign", handler.AssignUser)
-	uAuth.DELETE("/:id/delete", handler.DeleteUser)
-
-	// PATH /posts
-	p := e.Group("/posts")
-	pAuth := p.Group("/:id")
-	pAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	pAuth.DELETE("/delete", handler.DeletePost)
-	pAuth.PUT("/publish", handler.PublishPost)
-	pAuth.PUT("/retract", handler.RetractPost)
-
-	// PATH /posts/:id/reactions
-	pr := p.Group("/:id/reactions")
-	pr.GET("", handler.GetPostReactions)
-	pr.GET("/:reaction", handler.GetPostReactionByID)
-
-	// PATH /posts/:id/reactions/restricted
-	prAuth := pr.Group("/restricted")
-	prAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	prAuth.POST("/create", handler.CreateReaction)
-	prAuth.PUT("/:reaction/update", handler.UpdateReaction)
-	prAuth.DELETE("/:reaction/delete", handler.DeleteReaction)
-
-	// PATH /posts/articles
-	a := p.Group("/articles")
-	a.GET("/public", handler.GetPublicArticles)
-	a.GET("/public/:id", handler.GetPublicArticleByID)
-
-	aAuth := a.Group("")
-	aAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	aAuth.GET("", handler.GetArticles)
-	aAuth.GET("/:id", handler.GetArticleByID)
-	aAuth.POST("/create", handler.CreateArticle)
-	aAuth.PUT("/:id/update", handler.UpdateArticle)
-	aAuth.PUT("/:id/transfer", handler.TransferArticle)
-
-	// PATH /posts/galleries
-	g := p.Group("/galleries")
-	g.GET("/public", handler.GetPublicGalleries)
-	g.GET("/public/:id", handler.GetPublicGalleryByID)
-
-	gAuth := g.Group("")
-	gAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	gAuth.GET("", handler.GetGalleries)
-	gAuth.GET("/:id", handler.GetGalleryByID)
-	gAuth.POST("/create", handler.CreateGallery)
-	gAuth.PUT("/:id/update", handler.UpdateGallery)
-	gAuth.PUT("/:id/transfer", handler.TransferGallery)
-
-	// PATH /posts/flickers
-	f := p.Group("/flickers")
-	f.GET("/public", handler.GetPublicFlickers)
-	f.GET("/public/:id", handler.GetPublicFlickerByID)
-
-	fAuth := f.Group("")
-	fAuth.Use(middleware.JWTWithConfig(jwtConfig))
-	fAuth.GET("", handler.GetFlickers)
-	fAuth.GET("/:id", handler.GetFlickerByID)
-	fAuth.POST("/create", handler.CreateFlicker)
-	fAuth.PUT("/:id/update", handler.UpdateFlicker)
-	fAuth.PUT("/:id/transfer", handler.TransferFlicker)
-
-	e.HTTPErrorHandler = func(err error, c echo.Context) {
-		code := http.StatusInternalServerError
-		if he, ok := err.(*echo.HTTPError); ok {
-			code = he.Code
-		}
-
-		resp := handler.Response{
-			Message: http.StatusText(code),
-		}
-
-		c.JSON(code, resp)
-		c.Logger().Error(err)
-	}
+	handler.Routes(e, jwtConfig)
 
-	e.GET("/", handler.AppController)
+	e.HTTPErrorHandler = handler.ErrorHandler
+	e.Validator = handler.NewValidator()
 	e.Logger.Fatal(e.Start(":" + port))
 }
 
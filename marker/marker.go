@@ -0,0 +1,60 @@
+package marker
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest marker Normalize will return, in characters.
+const MaxLength = 64
+
+// Normalize turns s into its canonical marker form: lowercase, accents
+// folded, and runs of spaces, underscores and hyphens as one hyphen.
+// Letters, digits and the "+", "#" and "." of names like "c++", "c#" and
+// "node.js" are kept; anything else is dropped.
+func Normalize(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(strings.TrimSpace(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#' || r == '.':
+			if hyphen && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+		case unicode.IsSpace(r) || r == '_' || r == '-':
+			hyphen = true
+		}
+	}
+
+	out := []rune(b.String())
+	if len(out) > MaxLength {
+		return strings.TrimRight(string(out[:MaxLength]), "-")
+	}
+	return string(out)
+}
+
+// NormalizeAll normalizes every marker in markers, following aliases from
+// a marker to the one it was merged into, and drops empty and repeated
+// markers while keeping the original order.
+func NormalizeAll(markers []string, aliases map[string]string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, m := range markers {
+		m = Normalize(m)
+		if to, ok := aliases[m]; ok {
+			m = to
+		}
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out
+}
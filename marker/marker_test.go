@@ -0,0 +1,43 @@
+package marker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Case Test", in: "Go", want: "go"},
+		{name: "Spacing Test", in: "  Machine   Learning ", want: "machine-learning"},
+		{name: "Separator Test", in: "web_dev--tips", want: "web-dev-tips"},
+		{name: "Accent Test", in: "Café", want: "cafe"},
+		{name: "Symbol Test", in: "C++", want: "c++"},
+		{name: "Sharp Test", in: "C#", want: "c#"},
+		{name: "Dot Test", in: "Node.js", want: "node.js"},
+		{name: "Punctuation Test", in: "go!", want: "go"},
+		{name: "Empty Test", in: " -_ ", want: ""},
+		{name: "Length Test", in: strings.Repeat("ab-", 30), want: strings.TrimRight(strings.Repeat("ab-", 30)[:MaxLength], "-")},
+		{name: "Rune Length Test", in: strings.Repeat("日本", 40), want: strings.Repeat("日本", MaxLength/2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.in); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAll(t *testing.T) {
+	got := NormalizeAll([]string{"Go", "golang", "go", "", "Rust"}, map[string]string{"golang": "go"})
+	want := []string{"go", "rust"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeAll() = %v, want %v", got, want)
+	}
+}
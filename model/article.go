@@ -1,133 +1,154 @@
 package model
 
 import (
-	"net/http"
-
 	"github.com/jinzhu/gorm"
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/render"
+	"github.com/l3njo/yap/workflow"
+	uuid "github.com/satori/go.uuid"
 )
 
 // Article represents prose posts
 type Article struct {
 	PostBase
-	Content string `json:"content"`
+	Format      render.Format `json:"format"`
+	Content     string        `json:"content"`
+	ContentHTML string        `json:"content_html" sql:"-"`
+	WordCount   int           `json:"word_count" sql:"-"`
+	ReadingTime int           `json:"reading_time" sql:"-"`
+}
+
+// AfterFind renders an Article after it is loaded
+func (a *Article) AfterFind() error {
+	a.render()
+	return nil
+}
+
+// AfterSave renders an Article after it is written
+func (a *Article) AfterSave() error {
+	a.render()
+	return nil
+}
+
+// render fills the computed fields of an Article from its Content
+func (a *Article) render() {
+	a.ContentHTML = render.HTML(a.Content, a.Format)
+	a.WordCount = render.Words(render.Text(a.ContentHTML))
+	a.ReadingTime = render.ReadingTime(a.WordCount)
+}
+
+// validateFormat checks that an Article is written in a supported Format
+func (a *Article) validateFormat() error {
+	if !render.Valid(a.Format) {
+		return errs.Invalid(errs.Field{Name: "format", Code: errs.FieldInvalid, Message: "Format must be markdown, html or plain."})
+	}
+	return nil
 }
 
 // Create makes an Article
-func (a *Article) Create() (int, error) {
+func (a *Article) Create() error {
+	if err := a.validateFormat(); err != nil {
+		return err
+	}
+
+	if a.Format == "" {
+		a.Format = render.FormatMarkdown
+	}
+
+	s, err := uniqueSlug(articlePost, a.Subject, uuid.Nil)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	a.Slug = s
+	if err := a.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	article := Article{
 		PostBase: PostBase{
 			Subject: a.Subject,
+			Slug:    a.Slug,
 			Summary: a.Summary,
 			Overlay: a.Overlay,
 			Section: a.Section,
 			Pattern: articlePost,
 			Creator: a.Creator,
 			Markers: a.Markers,
+			Version: 1,
+			State:   workflow.Draft,
 		},
+		Format:  a.Format,
 		Content: a.Content,
 	}
 
 	if err := db.DB.Create(&article).Error; err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	*a = article
-	return http.StatusCreated, nil
+	return nil
 }
 
 // Read fetches an Article
-func (a *Article) Read() (int, error) {
-	if err := db.DB.Set("gorm:auto_preload", true).First(a).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
+func (a *Article) Read() error {
+	if err := db.DB.Set("gorm:auto_preload", true).First(a).Error; err != nil {
+		return dbError(err)
 	}
 
+	// Count the read in place, so it cannot write back a stale copy
 	a.Summons++
-	db.DB.Save(a)
-	return http.StatusOK, nil
+	db.DB.Model(a).UpdateColumn("summons", gorm.Expr("summons + 1"))
+	return nil
 }
 
 // Update edits an Article
-func (a *Article) Update() (int, error) {
+func (a *Article) Update() error {
+	if err := a.validateFormat(); err != nil {
+		return err
+	}
+
+	if err := a.setSlug(articlePost); err != nil {
+		return dbError(err)
+	}
+
+	if err := a.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	article := Article{
 		PostBase: PostBase{
 			Subject: a.Subject,
+			Slug:    a.Slug,
 			Summary: a.Summary,
 			Overlay: a.Overlay,
 			Section: a.Section,
 			Creator: a.Creator,
 			Markers: a.Markers,
+			Version: a.Version + 1,
 		},
+		Format:  a.Format,
 		Content: a.Content,
 	}
 
-	if err := db.DB.Model(a).Updates(article).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(a).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	if err := a.updateVersion(a, article); err != nil {
+		return err
 	}
 
-	return http.StatusAccepted, nil
+	return dbError(db.DB.Set("gorm:auto_preload", true).First(a).Error)
 }
 
 // Delete removes an Article
-func (a *Article) Delete() (int, error) {
-	res := db.DB.Delete(a)
-	if num, err := res.RowsAffected, res.Error; num == 0 {
-		return http.StatusNotFound, gorm.ErrRecordNotFound
-	} else if err != nil {
-		return http.StatusInternalServerError, nil
-	}
-
-	return http.StatusAccepted, nil
-}
-
-// Publish makes an Article public
-func (a *Article) Publish() (int, error) {
-	if a.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(a).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	a.Release, a.Summons = true, 0
-	db.DB.Save(a)
-	return http.StatusAccepted, nil
-}
-
-// Retract makes an Article private
-func (a *Article) Retract() (int, error) {
-	if !a.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(a).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	a.Release, a.Summons = false, 0
-	db.DB.Save(a)
-	return http.StatusAccepted, nil
+func (a *Article) Delete() error {
+	return deleteError(db.DB.Delete(a))
 }
 
 // ReadAllArticles fetches all Articles
-func ReadAllArticles() ([]Article, int, error) {
+func ReadAllArticles() ([]Article, error) {
 	articles := []Article{}
-	if err := db.DB.Set("gorm:auto_preload", true).Find(&articles).Error; gorm.IsRecordNotFoundError(err) {
-		return articles, http.StatusNotFound, err
+	if err := db.DB.Set("gorm:auto_preload", true).Find(&articles).Error; err != nil {
+		return articles, dbError(err)
 	}
 
-	return articles, http.StatusOK, nil
+	return articles, nil
 }
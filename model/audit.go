@@ -0,0 +1,130 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Actor is who made a change and where they made it from
+type Actor struct {
+	User  uuid.UUID
+	IP    string
+	Agent string
+}
+
+// AuditAction names a privileged change
+type AuditAction string
+
+// AuditActions recorded in the audit log. Workflow actions on Posts are
+// recorded as "post." followed by the workflow.Action, as in "post.publish".
+const (
+	AuditUserAssign     AuditAction = "user.assign"
+	AuditUserDelete     AuditAction = "user.delete"
+	AuditPostTransfer   AuditAction = "post.transfer"
+	AuditReactionDelete AuditAction = "reaction.delete"
+)
+
+// AuditEntry records who made a privileged change, to what, and how.
+// Entries are only ever inserted; the database rejects changes to them,
+// save blanking IP and Agent when their actor is erased.
+type AuditEntry struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+	Actor     uuid.UUID      `json:"actor" gorm:"type:uuid;index"`
+	Action    AuditAction    `json:"action" gorm:"index"`
+	Kind      string         `json:"kind"`
+	Target    uuid.UUID      `json:"target" gorm:"type:uuid;index"`
+	Before    postgres.Jsonb `json:"before" gorm:"type:jsonb"`
+	After     postgres.Jsonb `json:"after" gorm:"type:jsonb"`
+	IP        string         `json:"ip"`
+	Agent     string         `json:"agent"`
+}
+
+// AuditFilter narrows a read of the audit log. Zero fields match everything.
+type AuditFilter struct {
+	Actor  uuid.UUID
+	Action AuditAction
+	Kind   string
+	Target uuid.UUID
+	Since  time.Time
+	Before time.Time
+	Limit  int
+}
+
+// audit records a change to target by actor in tx.
+// before and after are the values that changed, marshalled as JSON.
+func audit(tx *gorm.DB, by Actor, action AuditAction, kind string, target uuid.UUID, before, after interface{}) error {
+	entry := AuditEntry{
+		ID:     uuid.NewV4(),
+		Actor:  by.User,
+		Action: action,
+		Kind:   kind,
+		Target: target,
+		IP:     by.IP,
+		Agent:  by.Agent,
+	}
+
+	var err error
+	if entry.Before.RawMessage, err = json.Marshal(before); err != nil {
+		return err
+	}
+	if entry.After.RawMessage, err = json.Marshal(after); err != nil {
+		return err
+	}
+	return tx.Create(&entry).Error
+}
+
+// ReadAuditLog fetches the entries of the audit log matching f, newest first
+func ReadAuditLog(f AuditFilter) ([]AuditEntry, error) {
+	query := db.DB.Where(&AuditEntry{Actor: f.Actor, Action: f.Action, Kind: f.Kind, Target: f.Target})
+	if !f.Since.IsZero() {
+		query = query.Where("created_at >= ?", f.Since)
+	}
+	if !f.Before.IsZero() {
+		query = query.Where("created_at < ?", f.Before)
+	}
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+
+	entries := []AuditEntry{}
+	if err := query.Order("created_at desc").Find(&entries).Error; err != nil {
+		return entries, errs.Internal(err)
+	}
+
+	return entries, nil
+}
+
+// redactAuditLog blanks the IP and Agent of the audit entries made by user in tx
+func redactAuditLog(tx *gorm.DB, user uuid.UUID) error {
+	return tx.Model(&AuditEntry{}).Where("actor = ? AND (ip <> '' OR agent <> '')", user).
+		UpdateColumns(map[string]interface{}{"ip": "", "agent": ""}).Error
+}
+
+// protectAuditLog makes the database refuse to change or remove audit
+// entries, other than the blanking done by redactAuditLog
+func protectAuditLog() error {
+	table := db.DB.NewScope(&AuditEntry{}).TableName()
+	return db.DB.Exec(`CREATE OR REPLACE FUNCTION audit_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' AND NEW.ip = '' AND NEW.agent = ''
+				AND (NEW.id, NEW.created_at, NEW.actor, NEW.action, NEW.kind, NEW.target, NEW.before, NEW.after)
+					IS NOT DISTINCT FROM (OLD.id, OLD.created_at, OLD.actor, OLD.action, OLD.kind, OLD.target, OLD.before, OLD.after) THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'audit entries cannot be changed';
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS audit_append_only ON ` + table + `;
+		DROP TRIGGER IF EXISTS audit_no_truncate ON ` + table + `;
+		CREATE TRIGGER audit_append_only BEFORE UPDATE OR DELETE ON ` + table + `
+			FOR EACH ROW EXECUTE PROCEDURE audit_append_only();
+		CREATE TRIGGER audit_no_truncate BEFORE TRUNCATE ON ` + table + `
+			FOR EACH STATEMENT EXECUTE PROCEDURE audit_append_only();`).Error
+}
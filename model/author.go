@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuthorRole is what an author may do with a Post
+type AuthorRole string
+
+// AuthorRoles of a Post. The Post's Creator is always an owner.
+// Owners and co-authors may edit it; reviewers may read it and its history
+// before it is released, and ask for changes while it is in review.
+const (
+	AuthorOwner    AuthorRole = "owner"
+	AuthorCoauthor AuthorRole = "coauthor"
+	AuthorReviewer AuthorRole = "reviewer"
+)
+
+// PostAuthor is a User invited to work on a Post.
+// The invitation stands until the User accepts it.
+type PostAuthor struct {
+	Base
+	Post     uuid.UUID  `json:"post" gorm:"type:uuid;unique_index:idx_author_post_user"`
+	User     uuid.UUID  `json:"user" gorm:"type:uuid;unique_index:idx_author_post_user;index"`
+	Role     AuthorRole `json:"role"`
+	Inviter  uuid.UUID  `json:"inviter" gorm:"type:uuid"`
+	Accepted *time.Time `json:"accepted_at"`
+}
+
+// Invite makes a PostAuthor, or changes the role of an existing one
+func (pa *PostAuthor) Invite() error {
+	existing := PostAuthor{}
+	err := db.DB.Where(&PostAuthor{Post: pa.Post, User: pa.User}).First(&existing).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return dbError(db.DB.Create(pa).Error)
+	} else if err != nil {
+		return errs.Internal(err)
+	}
+
+	if err := db.DB.Model(&existing).Update("role", pa.Role).Error; err != nil {
+		return errs.Internal(err)
+	}
+	*pa = existing
+	return nil
+}
+
+// Accept takes up a User's invitation to a Post
+func (pa *PostAuthor) Accept() error {
+	if err := db.DB.Where(&PostAuthor{Post: pa.Post, User: pa.User}).First(pa).Error; err != nil {
+		return dbError(err)
+	}
+
+	if pa.Accepted != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	pa.Accepted = &now
+	return dbError(db.DB.Model(pa).Update("accepted", pa.Accepted).Error)
+}
+
+// Delete removes a User from a Post, or withdraws their invitation
+func (pa *PostAuthor) Delete() error {
+	return deleteError(db.DB.Unscoped().Where(&PostAuthor{Post: pa.Post, User: pa.User}).Delete(&PostAuthor{}))
+}
+
+// ReadPostAuthors fetches everyone invited to a Post
+func ReadPostAuthors(post uuid.UUID) ([]PostAuthor, error) {
+	authors := []PostAuthor{}
+	if err := db.DB.Where(&PostAuthor{Post: post}).Order("created_at").Find(&authors).Error; err != nil {
+		return authors, dbError(err)
+	}
+
+	return authors, nil
+}
+
+// AuthorRoleOf returns the role user has accepted on a Post, if any.
+// The Creator of a Post is its owner.
+func AuthorRoleOf(pb *PostBase, user uuid.UUID) (AuthorRole, error) {
+	if uuid.Equal(pb.Creator, user) {
+		return AuthorOwner, nil
+	}
+
+	pa := PostAuthor{}
+	err := db.DB.Where(&PostAuthor{Post: pb.ID, User: user}).Where("accepted IS NOT NULL").First(&pa).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", errs.Internal(err)
+	}
+
+	return pa.Role, nil
+}
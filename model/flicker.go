@@ -1,10 +1,11 @@
 package model
 
 import (
-	"net/http"
-
 	"github.com/jinzhu/gorm"
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/workflow"
+	uuid "github.com/satori/go.uuid"
 )
 
 // Flicker represents video posts
@@ -15,121 +16,96 @@ type Flicker struct {
 }
 
 // Create makes a Flicker
-func (f *Flicker) Create() (int, error) {
+func (f *Flicker) Create() error {
+	s, err := uniqueSlug(flickerPost, f.Subject, uuid.Nil)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	f.Slug = s
+	if err := f.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	flicker := Flicker{
 		PostBase: PostBase{
 			Subject: f.Subject,
+			Slug:    f.Slug,
 			Summary: f.Summary,
 			Overlay: f.Overlay,
 			Section: f.Section,
+			Pattern: flickerPost,
 			Creator: f.Creator,
 			Markers: f.Markers,
+			Version: 1,
+			State:   workflow.Draft,
 		},
 		Content: f.Content,
 		Caption: f.Caption,
 	}
 
 	if err := db.DB.Create(&flicker).Error; err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	*f = flicker
-	return http.StatusCreated, nil
+	return nil
 }
 
 // Read fetches a Flicker
-func (f *Flicker) Read() (int, error) {
-	if err := db.DB.Set("gorm:auto_preload", true).First(f).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
+func (f *Flicker) Read() error {
+	if err := db.DB.Set("gorm:auto_preload", true).First(f).Error; err != nil {
+		return dbError(err)
 	}
 
+	// Count the read in place, so it cannot write back a stale copy
 	f.Summons++
-	db.DB.Save(f)
-	return http.StatusOK, nil
+	db.DB.Model(f).UpdateColumn("summons", gorm.Expr("summons + 1"))
+	return nil
 }
 
 // Update edits a Flicker
-func (f *Flicker) Update() (int, error) {
+func (f *Flicker) Update() error {
+	if err := f.setSlug(flickerPost); err != nil {
+		return dbError(err)
+	}
+
+	if err := f.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	flicker := Flicker{
 		PostBase: PostBase{
 			Subject: f.Subject,
+			Slug:    f.Slug,
 			Summary: f.Summary,
 			Overlay: f.Overlay,
 			Section: f.Section,
 			Creator: f.Creator,
 			Markers: f.Markers,
+			Version: f.Version + 1,
 		},
 		Content: f.Content,
 		Caption: f.Caption,
 	}
 
-	if err := db.DB.Model(f).Updates(flicker).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	if err := f.updateVersion(f, flicker); err != nil {
+		return err
 	}
 
-	return http.StatusAccepted, nil
+	return dbError(db.DB.Set("gorm:auto_preload", true).First(f).Error)
 }
 
 // Delete removes a Flicker
-func (f *Flicker) Delete() (int, error) {
-	res := db.DB.Delete(f)
-	if num, err := res.RowsAffected, res.Error; num == 0 {
-		return http.StatusNotFound, gorm.ErrRecordNotFound
-	} else if err != nil {
-		return http.StatusInternalServerError, nil
-	}
-
-	return http.StatusAccepted, nil
-}
-
-// Publish makes a Flicker public
-func (f *Flicker) Publish() (int, error) {
-	if f.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(f).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	f.Release, f.Summons = true, 0
-	db.DB.Save(f)
-	return http.StatusAccepted, nil
-}
-
-// Retract makes makes a Flicker private
-func (f *Flicker) Retract() (int, error) {
-	if !f.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(f).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	f.Release, f.Summons = true, 0
-	db.DB.Save(f)
-	return http.StatusAccepted, nil
+func (f *Flicker) Delete() error {
+	return deleteError(db.DB.Delete(f))
 }
 
 // ReadAllFlickers fetches all Flickers
-func ReadAllFlickers() ([]Flicker, int, error) {
+func ReadAllFlickers() ([]Flicker, error) {
 	flickers := []Flicker{}
-	if err := db.DB.Set("gorm:auto_preload", true).Find(&flickers).Error; gorm.IsRecordNotFoundError(err) {
-		return flickers, http.StatusNotFound, err
+	if err := db.DB.Set("gorm:auto_preload", true).Find(&flickers).Error; err != nil {
+		return flickers, dbError(err)
 	}
 
-	return flickers, http.StatusOK, nil
+	return flickers, nil
 }
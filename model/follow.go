@@ -0,0 +1,188 @@
+package model
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/marker"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// FollowKind is what a User follows
+type FollowKind string
+
+// FollowKinds a User can follow
+const (
+	FollowUser    FollowKind = "user"
+	FollowSection FollowKind = "section"
+	FollowMarker  FollowKind = "marker"
+)
+
+// Follow records a User following another User, a section or a marker.
+// Target holds the followed User's ID, or the section or marker name.
+type Follow struct {
+	Base
+	User   uuid.UUID  `json:"user" gorm:"type:uuid;unique_index:idx_follow_user_kind_target"`
+	Kind   FollowKind `json:"kind" gorm:"unique_index:idx_follow_user_kind_target"`
+	Target string     `json:"target" gorm:"unique_index:idx_follow_user_kind_target"`
+}
+
+// FollowCounts are the sizes of a User's social graph
+type FollowCounts struct {
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+}
+
+// Create makes a Follow, or reads it if the User already follows Target.
+// Marker targets are normalized first.
+func (f *Follow) Create() error {
+	if f.Kind == FollowMarker {
+		aliases, err := markerAliases()
+		if err != nil {
+			return err
+		}
+		if f.Target = marker.Normalize(f.Target); aliases[f.Target] != "" {
+			f.Target = aliases[f.Target]
+		}
+		if f.Target == "" {
+			return errs.Invalid(errs.Field{Name: "target", Code: errs.FieldInvalid, Message: "Must contain a letter or digit."})
+		}
+	}
+
+	return dbError(db.DB.Where(Follow{User: f.User, Kind: f.Kind, Target: f.Target}).FirstOrCreate(f).Error)
+}
+
+// Delete removes a Follow of its User for good, so it can be made again later.
+// It is found by ID when it has one, and by Kind and Target otherwise.
+func (f *Follow) Delete() error {
+	q := db.DB.Unscoped().Where(`"user" = ?`, f.User)
+	if uuid.Equal(f.ID, uuid.Nil) {
+		q = q.Where("kind = ? AND target = ?", f.Kind, f.Target)
+	} else {
+		q = q.Where("id = ?", f.ID)
+	}
+	return deleteError(q.Delete(&Follow{}))
+}
+
+// ReadUserFollows fetches everything user follows
+func ReadUserFollows(user uuid.UUID) ([]Follow, error) {
+	follows := []Follow{}
+	if err := db.DB.Where(Follow{User: user}).Order("created_at desc").Find(&follows).Error; err != nil {
+		return follows, dbError(err)
+	}
+
+	return follows, nil
+}
+
+// ReadFollowers fetches the Users following user
+func ReadFollowers(user uuid.UUID) ([]User, error) {
+	followers := db.DB.Model(&Follow{}).Select("\"user\"").Where(Follow{Kind: FollowUser, Target: user.String()}).QueryExpr()
+	users := []User{}
+	if err := db.DB.Where("id IN (?)", followers).Find(&users).Error; err != nil {
+		return users, dbError(err)
+	}
+
+	return users, nil
+}
+
+// ReadFollowing fetches the Users user follows
+func ReadFollowing(user uuid.UUID) ([]User, error) {
+	following := db.DB.Model(&Follow{}).Select("target").Where(Follow{User: user, Kind: FollowUser}).QueryExpr()
+	users := []User{}
+	if err := db.DB.Where("CAST(id AS text) IN (?)", following).Find(&users).Error; err != nil {
+		return users, dbError(err)
+	}
+
+	return users, nil
+}
+
+// CountFollows counts a User's followers and the Users they follow
+func CountFollows(user uuid.UUID) (FollowCounts, error) {
+	counts := FollowCounts{}
+	if err := db.DB.Model(&Follow{}).Where(Follow{Kind: FollowUser, Target: user.String()}).Count(&counts.Followers).Error; err != nil {
+		return counts, dbError(err)
+	}
+	if err := db.DB.Model(&Follow{}).Where(Follow{User: user, Kind: FollowUser}).Count(&counts.Following).Error; err != nil {
+		return counts, dbError(err)
+	}
+
+	return counts, nil
+}
+
+// ReadFeed fetches up to limit released Posts of every pattern by Users, in
+// sections or with markers that user follows, newest first. The page starts
+// after the Post released at before with ID beforeID, or at before when
+// beforeID is nil, so Posts released at the same time are neither skipped
+// nor repeated.
+func ReadFeed(user uuid.UUID, before time.Time, beforeID uuid.UUID, limit int) ([]Post, error) {
+	follows, err := ReadUserFollows(user)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[FollowKind][]string{}
+	for _, f := range follows {
+		targets[f.Kind] = append(targets[f.Kind], f.Target)
+	}
+
+	var conds []string
+	var args []interface{}
+	if t := targets[FollowUser]; len(t) > 0 {
+		conds, args = append(conds, "CAST(creator AS text) IN (?)"), append(args, t)
+	}
+	if t := targets[FollowSection]; len(t) > 0 {
+		conds, args = append(conds, "section IN (?)"), append(args, t)
+	}
+	if t := targets[FollowMarker]; len(t) > 0 {
+		conds, args = append(conds, "markers && ?::varchar[]"), append(args, pq.StringArray(t))
+	}
+
+	posts := []Post{}
+	if len(conds) == 0 {
+		return posts, nil
+	}
+
+	query := db.DB.Set("gorm:auto_preload", true).
+		Where("release = ? AND (released_at < ? OR (released_at = ? AND id < ?))", true, before, before, beforeID).
+		Where(strings.Join(conds, " OR "), args...).
+		Order("released_at desc, id desc").
+		Limit(limit)
+
+	posts, err = findPosts(query)
+	if err != nil {
+		return posts, err
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		a, b := posts[i].Meta(), posts[j].Meta()
+		if !a.Released.Equal(*b.Released) {
+			return a.Released.After(*b.Released)
+		}
+		return bytes.Compare(a.ID.Bytes(), b.ID.Bytes()) > 0
+	})
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+
+	return posts, nil
+}
+
+// backfillReleases stamps posts released before released_at existed
+func backfillReleases() error {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		err := db.DB.Model(p.table()).
+			Where("release = ? AND released_at IS NULL", true).
+			UpdateColumn("released_at", gorm.Expr("updated_at")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
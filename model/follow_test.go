@@ -0,0 +1,91 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestFollow_Delete(t *testing.T) {
+	defer useTestDB(t, &Follow{})()
+	user := uuid.NewV4()
+	section := Follow{User: user, Kind: FollowSection, Target: "science"}
+	other := Follow{User: user, Kind: FollowUser, Target: uuid.NewV4().String()}
+	for _, f := range []*Follow{&section, &other} {
+		if err := f.Create(); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	byID := Follow{Base: Base{ID: section.ID}, User: user}
+	if err := byID.Delete(); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	left, err := ReadUserFollows(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || !uuid.Equal(left[0].ID, other.ID) {
+		t.Errorf("Delete() by ID left %v, want only %v", left, other.ID)
+	}
+
+	stranger := Follow{Base: Base{ID: other.ID}, User: uuid.NewV4()}
+	if err := stranger.Delete(); err == nil {
+		t.Errorf("Delete() of another user's follow error = nil, want not found")
+	}
+
+	byTarget := Follow{User: user, Kind: other.Kind, Target: other.Target}
+	if err := byTarget.Delete(); err != nil {
+		t.Fatalf("Delete() by target error = %v", err)
+	}
+	var count int
+	db.DB.Model(&Follow{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Delete() by target left %d follows, want 0", count)
+	}
+}
+
+func TestReadFeed(t *testing.T) {
+	defer useTestDB(t, &Follow{}, &Article{}, &Gallery{}, &Flicker{})()
+	user := uuid.NewV4()
+	follow := Follow{User: user, Kind: FollowSection, Target: "science"}
+	if err := follow.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Five posts, three of them released at the same time
+	at := time.Now().UTC().Truncate(time.Second)
+	times := []time.Time{at, at, at, at.Add(-time.Hour), at.Add(-2 * time.Hour)}
+	for i := range times {
+		a := Article{PostBase: PostBase{Subject: "Post", Section: "science", Pattern: articlePost, Release: true, Released: &times[i]}}
+		if err := db.DB.Create(&a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[uuid.UUID]bool{}
+	before, beforeID := at.Add(time.Second), uuid.Nil
+	for page := 0; page < 3; page++ {
+		posts, err := ReadFeed(user, before, beforeID, 2)
+		if err != nil {
+			t.Fatalf("ReadFeed() error = %v", err)
+		}
+		for _, p := range posts {
+			if seen[p.Meta().ID] {
+				t.Errorf("ReadFeed() page %d repeated %v", page, p.Meta().ID)
+			}
+			seen[p.Meta().ID] = true
+		}
+		if len(posts) == 0 {
+			break
+		}
+		last := posts[len(posts)-1].Meta()
+		before, beforeID = *last.Released, last.ID
+	}
+
+	if len(seen) != len(times) {
+		t.Errorf("ReadFeed() pages held %d posts, want %d", len(seen), len(times))
+	}
+}
@@ -1,11 +1,12 @@
 package model
 
 import (
-	"net/http"
-
 	"github.com/jinzhu/gorm"
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/workflow"
 	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
 )
 
 // Gallery represents image posts
@@ -16,122 +17,96 @@ type Gallery struct {
 }
 
 // Create makes a Gallery
-func (g *Gallery) Create() (int, error) {
+func (g *Gallery) Create() error {
+	s, err := uniqueSlug(galleryPost, g.Subject, uuid.Nil)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	g.Slug = s
+	if err := g.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	gallery := Gallery{
 		PostBase: PostBase{
 			Subject: g.Subject,
+			Slug:    g.Slug,
 			Summary: g.Summary,
 			Overlay: g.Overlay,
 			Section: g.Section,
 			Pattern: galleryPost,
 			Creator: g.Creator,
 			Markers: g.Markers,
+			Version: 1,
+			State:   workflow.Draft,
 		},
 		Content: g.Content,
 		Caption: g.Caption,
 	}
 
 	if err := db.DB.Create(&gallery).Error; err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	*g = gallery
-	return http.StatusCreated, nil
+	return nil
 }
 
 // Read fetches a Gallery
-func (g *Gallery) Read() (int, error) {
-	if err := db.DB.Set("gorm:auto_preload", true).First(g).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
+func (g *Gallery) Read() error {
+	if err := db.DB.Set("gorm:auto_preload", true).First(g).Error; err != nil {
+		return dbError(err)
 	}
 
+	// Count the read in place, so it cannot write back a stale copy
 	g.Summons++
-	db.DB.Save(g)
-	return http.StatusOK, nil
+	db.DB.Model(g).UpdateColumn("summons", gorm.Expr("summons + 1"))
+	return nil
 }
 
 // Update edits a Gallery
-func (g *Gallery) Update() (int, error) {
+func (g *Gallery) Update() error {
+	if err := g.setSlug(galleryPost); err != nil {
+		return dbError(err)
+	}
+
+	if err := g.normalizeMarkers(); err != nil {
+		return err
+	}
+
 	gallery := Gallery{
 		PostBase: PostBase{
 			Subject: g.Subject,
+			Slug:    g.Slug,
 			Summary: g.Summary,
 			Overlay: g.Overlay,
 			Section: g.Section,
 			Creator: g.Creator,
 			Markers: g.Markers,
+			Version: g.Version + 1,
 		},
 		Content: g.Content,
 		Caption: g.Caption,
 	}
 
-	if err := db.DB.Model(g).Updates(gallery).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	if err := g.updateVersion(g, gallery); err != nil {
+		return err
 	}
 
-	return http.StatusAccepted, nil
+	return dbError(db.DB.Set("gorm:auto_preload", true).First(g).Error)
 }
 
 // Delete removes a Gallery
-func (g *Gallery) Delete() (int, error) {
-	res := db.DB.Delete(g)
-	if num, err := res.RowsAffected, res.Error; num == 0 {
-		return http.StatusNotFound, gorm.ErrRecordNotFound
-	} else if err != nil {
-		return http.StatusInternalServerError, nil
-	}
-
-	return http.StatusAccepted, nil
-}
-
-// Publish makes a Gallery public
-func (g *Gallery) Publish() (int, error) {
-	if g.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(g).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	g.Release, g.Summons = true, 0
-	db.DB.Save(g)
-	return http.StatusAccepted, nil
-}
-
-// Retract makes makes a Gallery private
-func (g *Gallery) Retract() (int, error) {
-	if !g.Release {
-		return http.StatusNotModified, nil
-	}
-
-	if err := db.DB.Set("gorm:auto_preload", true).First(g).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	g.Release, g.Summons = false, 0
-	db.DB.Save(g)
-	return http.StatusAccepted, nil
+func (g *Gallery) Delete() error {
+	return deleteError(db.DB.Delete(g))
 }
 
 // ReadAllGalleries fetches all Galleries
-func ReadAllGalleries() ([]Gallery, int, error) {
+func ReadAllGalleries() ([]Gallery, error) {
 	galleries := []Gallery{}
-	if err := db.DB.Set("gorm:auto_preload", true).Find(&galleries).Error; gorm.IsRecordNotFoundError(err) {
-		return galleries, http.StatusNotFound, err
+	if err := db.DB.Set("gorm:auto_preload", true).Find(&galleries).Error; err != nil {
+		return galleries, dbError(err)
 	}
 
-	return galleries, http.StatusOK, nil
+	return galleries, nil
 }
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditIdentityLink is the AuditAction of linking a User to a provider account
+const AuditIdentityLink AuditAction = "user.link_identity"
+
+// Identity links a User to their account at an OpenID Connect provider
+type Identity struct {
+	Base
+	User     uuid.UUID `json:"user" gorm:"type:uuid;index"`
+	Provider string    `json:"provider" gorm:"unique_index:idx_identity_subject"`
+	Subject  string    `json:"subject" gorm:"unique_index:idx_identity_subject"`
+	Mail     string    `json:"mail"`
+}
+
+// ReadIdentities lists the provider accounts linked to a User
+func (u *User) ReadIdentities() ([]Identity, error) {
+	ids := []Identity{}
+	if err := db.DB.Where(&Identity{User: u.ID}).Order("created_at").Find(&ids).Error; err != nil {
+		return ids, errs.Internal(err)
+	}
+	return ids, nil
+}
+
+// LoginIdentity returns the User that subject at provider logs in as.
+// An unknown subject makes a new User once the provider has verified the
+// mail. It is only linked to an existing User with that mail when yap knows
+// the mail is theirs; other Users must log in and link it with LinkIdentity.
+func LoginIdentity(provider, subject, mail, name string, verified bool, by Actor) (User, error) {
+	user, id := User{}, Identity{}
+	err := db.DB.Where(&Identity{Provider: provider, Subject: subject}).First(&id).Error
+	if err == nil {
+		user.ID = id.User
+		return user, dbError(db.DB.First(&user).Error)
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return user, errs.Internal(err)
+	}
+
+	if mail == "" || !verified {
+		return user, errs.New(http.StatusForbidden, errs.CodeForbidden, "The provider has not verified your mail, so it cannot be linked to an account.")
+	}
+
+	err = db.DB.Where(&User{Mail: mail}).First(&user).Error
+	if gorm.IsRecordNotFoundError(err) {
+		if user, err = joinIdentity(mail, name); err != nil {
+			return user, err
+		}
+	} else if err != nil {
+		return user, errs.Internal(err)
+	} else if !user.MailVerified {
+		return User{}, errs.New(http.StatusConflict, errs.CodeMailTaken, "An account with this mail already exists. Log in to it and link "+provider+" from there.").
+			WithField("mail", string(errs.CodeMailTaken), "")
+	}
+
+	by.User = user.ID
+	return user, linkIdentity(user.ID, provider, subject, mail, by)
+}
+
+// LinkIdentity links subject at provider to the User with id, who is logged in
+func LinkIdentity(id uuid.UUID, provider, subject, mail string, by Actor) (User, error) {
+	user := User{Base: Base{ID: id}}
+	if err := db.DB.First(&user).Error; err != nil {
+		return user, dbError(err)
+	}
+
+	linked := Identity{}
+	err := db.DB.Where(&Identity{Provider: provider, Subject: subject}).First(&linked).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return user, linkIdentity(id, provider, subject, mail, by)
+	} else if err != nil {
+		return user, errs.Internal(err)
+	}
+
+	if !uuid.Equal(linked.User, id) {
+		return user, errs.New(http.StatusConflict, errs.CodeConflict, "This "+provider+" account is linked to another user.")
+	}
+	return user, nil
+}
+
+// linkIdentity records that subject at provider is the User with id
+func linkIdentity(user uuid.UUID, provider, subject, mail string, by Actor) error {
+	id := Identity{User: user, Provider: provider, Subject: subject, Mail: mail}
+	tx := db.DB.Begin()
+	err := tx.Create(&id).Error
+	if err == nil {
+		err = audit(tx, by, AuditIdentityLink, "user", user, nil, map[string]string{"provider": provider, "subject": subject})
+	}
+	if err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+	return dbError(tx.Commit().Error)
+}
+
+// joinIdentity makes a User for a verified mail from a provider.
+// Their password is random, so they log in with the provider, and their
+// mail counts as verified.
+func joinIdentity(mail, name string) (User, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return User{}, errs.Internal(err)
+	}
+
+	if name == "" {
+		name = strings.SplitN(mail, "@", 2)[0]
+	}
+	user := User{Name: name, Mail: mail, Pass: hex.EncodeToString(b), MailVerified: true}
+	if err := user.Create(); err != nil {
+		return user, err
+	}
+	return user, nil
+}
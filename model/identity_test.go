@@ -0,0 +1,43 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestLoginIdentity(t *testing.T) {
+	defer useTestDB(t, &User{}, &Identity{}, &AuditEntry{})()
+	squatter := User{Name: "Mallory", Mail: "ada@example.com", Pass: "engine42"}
+	if err := squatter.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoginIdentity("local", "ada", "ada@example.com", "Ada", true, Actor{})
+	if got := errs.From(err).Status; got != http.StatusConflict {
+		t.Fatalf("LoginIdentity() onto an unverified mail status = %v, want %v", got, http.StatusConflict)
+	}
+
+	linked, err := LinkIdentity(squatter.ID, "local", "mallory", "mallory@example.com", Actor{User: squatter.ID})
+	if err != nil || !uuid.Equal(linked.ID, squatter.ID) {
+		t.Fatalf("LinkIdentity() = %v, %v, want %v", linked.ID, err, squatter.ID)
+	}
+	if _, err := LinkIdentity(uuid.NewV4(), "local", "mallory", "", Actor{}); err == nil {
+		t.Error("LinkIdentity() of another user's account error = nil, want one")
+	}
+
+	joined, err := LoginIdentity("local", "grace", "grace@example.com", "Grace", true, Actor{})
+	if err != nil || !joined.MailVerified {
+		t.Fatalf("LoginIdentity() of a new mail = %v, %v, want a verified user", joined.MailVerified, err)
+	}
+	again, err := LoginIdentity("other", "grace", "grace@example.com", "Grace", true, Actor{})
+	if err != nil || !uuid.Equal(again.ID, joined.ID) {
+		t.Errorf("LoginIdentity() onto a verified mail = %v, %v, want %v", again.ID, err, joined.ID)
+	}
+
+	if _, err := LoginIdentity("local", "eve", "eve@example.com", "Eve", false, Actor{}); err == nil {
+		t.Error("LoginIdentity() with an unverified provider mail error = nil, want one")
+	}
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/marker"
+	"github.com/lib/pq"
+)
+
+// MarkerAlias sends a marker that was merged away to the marker it was merged into,
+// so writes using the old marker keep landing on the new one.
+type MarkerAlias struct {
+	Base
+	Name      string `json:"name" gorm:"unique_index"`
+	Canonical string `json:"canonical"`
+}
+
+// MarkerCount is a marker and how many released Posts carry it
+type MarkerCount struct {
+	Name  string `json:"name"`
+	Posts int    `json:"posts"`
+}
+
+// markerAliases fetches every MarkerAlias as a map from old to new marker
+func markerAliases() (map[string]string, error) {
+	aliases := []MarkerAlias{}
+	if err := db.DB.Find(&aliases).Error; err != nil {
+		return nil, errs.Internal(err)
+	}
+
+	m := map[string]string{}
+	for _, a := range aliases {
+		m[a.Name] = a.Canonical
+	}
+	return m, nil
+}
+
+// normalizeMarkers puts the markers of pb in canonical form
+func (pb *PostBase) normalizeMarkers() error {
+	aliases, err := markerAliases()
+	if err != nil {
+		return err
+	}
+
+	pb.Markers = marker.NormalizeAll(pb.Markers, aliases)
+	return nil
+}
+
+// canonicalMarker normalises name and follows its alias, if it has one
+func canonicalMarker(name string) (string, error) {
+	aliases, err := markerAliases()
+	if err != nil {
+		return "", err
+	}
+
+	if names := marker.NormalizeAll([]string{name}, aliases); len(names) > 0 {
+		return names[0], nil
+	}
+	return "", nil
+}
+
+// markerTables is a UNION ALL of the markers of every released Post, one row each
+func markerTables() string {
+	var parts []string
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		table := db.DB.NewScope(p.table()).TableName()
+		parts = append(parts, "SELECT unnest(markers) AS name FROM "+table+" WHERE release = true AND deleted_at IS NULL")
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+// ReadMarkers counts the released Posts carrying each marker that starts with
+// prefix, most used first. A limit of 0 reads every marker.
+func ReadMarkers(prefix string, limit int) ([]MarkerCount, error) {
+	query := "SELECT name, COUNT(*) AS posts FROM (" + markerTables() + ") AS m WHERE name LIKE ? GROUP BY name ORDER BY posts DESC, name"
+	args := []interface{}{strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	counts := []MarkerCount{}
+	if err := db.DB.Raw(query, args...).Scan(&counts).Error; err != nil {
+		return counts, dbError(err)
+	}
+
+	return counts, nil
+}
+
+// ReadMarkerPosts fetches the released Posts of every pattern carrying a marker,
+// newest first. A marker that was merged away reads the marker it became.
+func ReadMarkerPosts(name string) ([]Post, error) {
+	name, err := canonicalMarker(name)
+	if err != nil || name == "" {
+		return []Post{}, err
+	}
+
+	query := db.DB.Set("gorm:auto_preload", true).
+		Where("release = ? AND markers @> ?::varchar[]", true, pq.StringArray{name}).
+		Order("released_at desc")
+
+	posts, err := findPosts(query)
+	if err != nil {
+		return posts, err
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := posts[i].Meta().Released, posts[j].Meta().Released
+		return a != nil && (b == nil || a.After(*b))
+	})
+	return posts, nil
+}
+
+// MergeMarkers replaces every marker in from with to on every Post and
+// marker Follow, and aliases from to to for later writes.
+// It returns how many Posts changed.
+func MergeMarkers(from []string, to string) (int, error) {
+	to = marker.Normalize(to)
+	sources := []string{}
+	for _, m := range marker.NormalizeAll(from, nil) {
+		if m != to {
+			sources = append(sources, m)
+		}
+	}
+	if to == "" || len(sources) == 0 {
+		return 0, nil
+	}
+
+	replace := map[string]string{}
+	for _, m := range sources {
+		replace[m] = to
+	}
+
+	changed := 0
+	tx := db.DB.Begin()
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		rows, err := tx.Model(p.table()).Unscoped().Select("id, markers").Where("markers && ?::varchar[]", pq.StringArray(sources)).Rows()
+		if err != nil {
+			tx.Rollback()
+			return 0, errs.Internal(err)
+		}
+
+		pending := []PostBase{}
+		for rows.Next() {
+			pb := PostBase{}
+			if err := rows.Scan(&pb.ID, &pb.Markers); err != nil {
+				rows.Close()
+				tx.Rollback()
+				return 0, errs.Internal(err)
+			}
+			pending = append(pending, pb)
+		}
+		rows.Close()
+
+		for _, pb := range pending {
+			markers := pq.StringArray(marker.NormalizeAll(pb.Markers, replace))
+			if err := tx.Model(p.table()).Unscoped().Where("id = ?", pb.ID).UpdateColumn("markers", markers).Error; err != nil {
+				tx.Rollback()
+				return 0, errs.Internal(err)
+			}
+			changed++
+		}
+	}
+
+	// Users following several of the merged markers keep one follow
+	err := tx.Exec(`DELETE FROM follows f USING follows g
+		WHERE f.kind = ? AND g.kind = ? AND f."user" = g."user" AND f.target IN (?)
+		AND (g.target = ? OR (g.target IN (?) AND g.id < f.id))`,
+		FollowMarker, FollowMarker, sources, to, sources).Error
+	if err == nil {
+		err = tx.Model(&Follow{}).Where("kind = ? AND target IN (?)", FollowMarker, sources).UpdateColumn("target", to).Error
+	}
+	if err == nil {
+		err = tx.Unscoped().Where(MarkerAlias{Name: to}).Delete(&MarkerAlias{}).Error
+	}
+	if err == nil {
+		err = tx.Model(&MarkerAlias{}).Where("canonical IN (?)", sources).UpdateColumn("canonical", to).Error
+	}
+	for _, m := range sources {
+		if err != nil {
+			break
+		}
+		err = tx.Where(MarkerAlias{Name: m}).Assign(MarkerAlias{Canonical: to}).FirstOrCreate(&MarkerAlias{}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return 0, errs.Internal(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, errs.Internal(err)
+	}
+	return changed, nil
+}
+
+// backfillMarkers normalizes markers written before normalization existed
+func backfillMarkers() error {
+	aliases, err := markerAliases()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		rows, err := db.DB.Model(p.table()).Unscoped().Select("id, markers").Where("array_length(markers, 1) > 0").Rows()
+		if err != nil {
+			return err
+		}
+
+		pending := []PostBase{}
+		for rows.Next() {
+			pb := PostBase{}
+			if err := rows.Scan(&pb.ID, &pb.Markers); err != nil {
+				rows.Close()
+				return err
+			}
+			if normal := pq.StringArray(marker.NormalizeAll(pb.Markers, aliases)); !reflect.DeepEqual(normal, pb.Markers) {
+				pb.Markers = normal
+				pending = append(pending, pb)
+			}
+		}
+		rows.Close()
+
+		for _, pb := range pending {
+			if err := db.DB.Model(p.table()).Unscoped().Where("id = ?", pb.ID).UpdateColumn("markers", pb.Markers).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/db"
+)
+
+func TestCanonicalMarker(t *testing.T) {
+	defer useTestDB(t, &MarkerAlias{})()
+	if err := db.DB.Create(&MarkerAlias{Name: "golang", Canonical: "go"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"Go":      "go",
+		"GoLang":  "go",
+		"  rust ": "rust",
+		"!!":      "",
+	} {
+		if got, err := canonicalMarker(name); err != nil || got != want {
+			t.Errorf("canonicalMarker(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
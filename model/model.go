@@ -1,7 +1,9 @@
 package model
 
 import (
+	"github.com/jinzhu/gorm"
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
 )
 
 // InitDB connects to and sets up the database
@@ -9,9 +11,71 @@ func InitDB(url string) error {
 	if err := db.Init(url); err != nil {
 		return err
 	}
-	if err := db.DB.Debug().AutoMigrate(&User{}, &Article{}, &Gallery{}, &Flicker{}, &Question{}, &Response{}, &Reaction{}).Error; err != nil {
+	if err := db.DB.Debug().AutoMigrate(&User{}, &Article{}, &Gallery{}, &Flicker{}, &Question{}, &Response{}, &Reaction{}, &PostSlug{}, &Webhook{}, &WebhookDelivery{}, &Notification{}, &NotificationPreference{}, &Follow{}, &ReadingList{}, &ReadingItem{}, &Series{}, &SeriesEntry{}, &MarkerAlias{}, &PostAuthor{}, &PostTransition{}, &AuditEntry{}, &RecoveryCode{}, &Setting{}, &Identity{}, &AccessToken{}, &Session{}).Error; err != nil {
 		return err
 	}
 
+	if err := protectAuditLog(); err != nil {
+		return err
+	}
+
+	if err := loadTwoFactorRoles(); err != nil {
+		return err
+	}
+
+	if err := sessionsSince.load(settingSessionsSince); err != nil {
+		return err
+	}
+
+	if err := keysSince.load(settingKeysSince); err != nil {
+		return err
+	}
+
+	if err := backfillHandles(); err != nil {
+		return err
+	}
+
+	if err := backfillSlugs(); err != nil {
+		return err
+	}
+
+	if err := indexSlugs(); err != nil {
+		return err
+	}
+
+	if err := backfillReleases(); err != nil {
+		return err
+	}
+
+	if err := backfillMarkers(); err != nil {
+		return err
+	}
+
+	if err := backfillStates(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// dbError converts a database error into an errs.Error
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if gorm.IsRecordNotFoundError(err) {
+		return errs.NotFound(err)
+	}
+	return errs.Internal(err)
+}
+
+// deleteError converts the result of a delete into an errs.Error
+func deleteError(res *gorm.DB) error {
+	if res.Error != nil {
+		return errs.Internal(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.NotFound(gorm.ErrRecordNotFound)
+	}
 	return nil
 }
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite" // INIT SQLite drivers for tests
+	"github.com/l3njo/yap/db"
+)
+
+// testDialect is SQLite, keeping Postgres array columns as text so models
+// using pq arrays can be migrated
+type testDialect struct {
+	gorm.Dialect
+}
+
+func init() {
+	gorm.RegisterDialect("yaptest", &testDialect{})
+}
+
+// SetDB makes a SQLite dialect for db
+func (d *testDialect) SetDB(db gorm.SQLCommon) {
+	base, _ := gorm.GetDialect("sqlite3")
+	d.Dialect = reflect.New(reflect.TypeOf(base).Elem()).Interface().(gorm.Dialect)
+	d.Dialect.SetDB(db)
+}
+
+// GetName is the name testDialect is registered under, so clones of a
+// connection keep it
+func (d *testDialect) GetName() string {
+	return "yaptest"
+}
+
+// DataTypeOf drops the array suffix of Postgres array types
+func (d *testDialect) DataTypeOf(field *gorm.StructField) string {
+	return strings.TrimSuffix(d.Dialect.DataTypeOf(field), "[]")
+}
+
+// useTestDB points db.DB at a fresh in-memory database holding tables for
+// models, and returns a func that puts the previous one back
+func useTestDB(t *testing.T, models ...interface{}) func() {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	conn, err := gorm.Open("yaptest", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.AutoMigrate(models...).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	previous := db.DB
+	db.DB = conn
+	return func() {
+		db.DB = previous
+		conn.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// NotificationKind is the reason a User was notified
+type NotificationKind string
+
+// NotificationKinds a User can receive and opt out of
+const (
+	NotifyComment  NotificationKind = "comment"
+	NotifyApproval NotificationKind = "approval"
+	NotifySticker  NotificationKind = "sticker"
+	NotifyTransfer NotificationKind = "transfer"
+	NotifyPublish  NotificationKind = "publish"
+	NotifyRetract  NotificationKind = "retract"
+	NotifyRole     NotificationKind = "role"
+	NotifyInvite   NotificationKind = "invite"
+	NotifyReview   NotificationKind = "review"
+)
+
+// NotificationKinds lists every NotificationKind
+var NotificationKinds = []NotificationKind{
+	NotifyComment, NotifyApproval, NotifySticker, NotifyTransfer, NotifyPublish, NotifyRetract, NotifyRole, NotifyInvite, NotifyReview,
+}
+
+// Notification tells a User something happened to them or their posts
+type Notification struct {
+	Base
+	User   uuid.UUID        `json:"user" gorm:"type:uuid;index"`
+	Kind   NotificationKind `json:"kind"`
+	Actor  uuid.UUID        `json:"actor" gorm:"type:uuid"`
+	Post   uuid.UUID        `json:"post" gorm:"type:uuid"`
+	Text   string           `json:"text"`
+	ReadAt *time.Time       `json:"read_at"`
+}
+
+// NotificationPreference records whether a User wants a NotificationKind
+type NotificationPreference struct {
+	Base
+	User    uuid.UUID        `json:"user" gorm:"type:uuid;unique_index:idx_preference_user_kind"`
+	Kind    NotificationKind `json:"kind" gorm:"unique_index:idx_preference_user_kind"`
+	Enabled bool             `json:"enabled"`
+}
+
+// Create makes a Notification unless its User has opted out of its Kind
+func (n *Notification) Create() error {
+	prefs, err := ReadNotificationPreferences(n.User)
+	if err != nil {
+		return err
+	}
+
+	if !prefs[n.Kind] {
+		return nil
+	}
+
+	return dbError(db.DB.Create(n).Error)
+}
+
+// MarkRead marks one of a User's Notifications as read
+func (n *Notification) MarkRead() error {
+	if err := db.DB.Where(&Notification{Base: Base{ID: n.ID}, User: n.User}).First(n).Error; err != nil {
+		return dbError(err)
+	}
+
+	if n.ReadAt != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	n.ReadAt = &now
+	return dbError(db.DB.Model(n).Update("read_at", now).Error)
+}
+
+// ReadUserNotifications fetches a User's latest Notifications and how many are unread
+func ReadUserNotifications(user uuid.UUID, unreadOnly bool, limit int) ([]Notification, int, error) {
+	notifications, unread := []Notification{}, 0
+	if err := db.DB.Model(&Notification{}).Where("\"user\" = ? AND read_at IS NULL", user).Count(&unread).Error; err != nil {
+		return notifications, unread, errs.Internal(err)
+	}
+
+	query := db.DB.Where("\"user\" = ?", user)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	err := query.Order("created_at desc").Limit(limit).Find(&notifications).Error
+	return notifications, unread, dbError(err)
+}
+
+// MarkAllNotificationsRead marks every Notification of a User as read
+func MarkAllNotificationsRead(user uuid.UUID) error {
+	err := db.DB.Model(&Notification{}).Where("\"user\" = ? AND read_at IS NULL", user).Update("read_at", time.Now().UTC()).Error
+	return dbError(err)
+}
+
+// ReadNotificationPreferences fetches which NotificationKinds a User wants.
+// Kinds without a stored preference are enabled.
+func ReadNotificationPreferences(user uuid.UUID) (map[NotificationKind]bool, error) {
+	prefs := map[NotificationKind]bool{}
+	for _, kind := range NotificationKinds {
+		prefs[kind] = true
+	}
+
+	stored := []NotificationPreference{}
+	if err := db.DB.Where(&NotificationPreference{User: user}).Find(&stored).Error; err != nil {
+		return prefs, dbError(err)
+	}
+
+	for _, p := range stored {
+		prefs[p.Kind] = p.Enabled
+	}
+	return prefs, nil
+}
+
+// UpdateNotificationPreferences stores a User's choices for the given NotificationKinds
+func UpdateNotificationPreferences(user uuid.UUID, prefs map[NotificationKind]bool) error {
+	for kind, enabled := range prefs {
+		pref := NotificationPreference{}
+		err := db.DB.Where(NotificationPreference{User: user, Kind: kind}).
+			Assign(map[string]interface{}{"enabled": enabled}).
+			FirstOrCreate(&pref).Error
+		if err != nil {
+			return errs.Internal(err)
+		}
+	}
+
+	return nil
+}
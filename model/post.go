@@ -1,9 +1,14 @@
 package model
 
 import (
-	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/workflow"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 )
@@ -11,13 +16,11 @@ import (
 // Post represents all post types
 type Post interface {
 	// Read fetches a Post
-	Read() (int, error)
+	Read() error
 	// Delete removes a Post
-	Delete() (int, error)
-	// Publish makes a Post public
-	Publish() (int, error)
-	// Retract makes a Post private
-	Retract() (int, error)
+	Delete() error
+	// Meta returns the fields common to all Posts
+	Meta() *PostBase
 }
 
 type postPattern string
@@ -37,35 +40,131 @@ type PostBase struct {
 	Section   string         `json:"section"`
 	Summons   int            `json:"summons"`
 	Release   bool           `json:"release"`
+	State     workflow.State `json:"state" gorm:"index;not null;default:'draft'"`
+	Released  *time.Time     `json:"released_at" gorm:"column:released_at;index"`
 	Pattern   postPattern    `json:"pattern"`
+	Slug      string         `json:"slug" gorm:"index"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
 	Creator   uuid.UUID      `json:"creator" gorm:"type:uuid"`
 	Markers   pq.StringArray `json:"markers" gorm:"type:varchar(255)[]"`
 	Reactions []Reaction     `json:"reactions,omitempty" sql:"-" gorm:"foreignkey:Post"`
+	Series    *SeriesNav     `json:"series,omitempty" sql:"-"`
+}
+
+// Meta returns the fields common to all Posts
+func (pb *PostBase) Meta() *PostBase {
+	return pb
+}
+
+// updateVersion writes values over p if it is still at pb.Version and State.
+// values should move Version on by one; a Post changed or moved through the
+// workflow by someone else since it was read is a conflict.
+func (pb *PostBase) updateVersion(p Post, values interface{}) error {
+	res := db.DB.Model(p).Where("version = ? AND state = ?", pb.Version, pb.State).Updates(values)
+	if res.Error != nil {
+		return dbError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.New(http.StatusConflict, errs.CodeStaleVersion, "This post was changed since it was read. Reload it and try again.")
+	}
+	return nil
+}
+
+// TransferPost gives p to creator on behalf of by
+func TransferPost(p Post, creator uuid.UUID, by Actor) error {
+	pb := p.Meta()
+	tx := db.DB.Begin()
+	res := tx.Model(p).Where("version = ?", pb.Version).Updates(map[string]interface{}{"creator": creator, "version": pb.Version + 1})
+	if res.Error != nil {
+		tx.Rollback()
+		return dbError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return errs.New(http.StatusConflict, errs.CodeStaleVersion, "This post was changed since it was read. Reload it and try again.")
+	}
+
+	before, after := map[string]uuid.UUID{"creator": pb.Creator}, map[string]uuid.UUID{"creator": creator}
+	if err := audit(tx, by, AuditPostTransfer, string(pb.Pattern), pb.ID, before, after); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+	return dbError(db.DB.Set("gorm:auto_preload", true).First(p).Error)
 }
 
 // GetPost finds a Post across Post models.
-func GetPost(id uuid.UUID) (Post, int, error) {
-	article := &Article{PostBase: PostBase{Base: Base{ID: id}}}
-	if status, err := article.Read(); err == nil {
-		return article, status, nil
-	} else if status != http.StatusNotFound {
-		return article, status, err
+func GetPost(id uuid.UUID) (Post, error) {
+	for _, post := range []Post{&Article{}, &Gallery{}, &Flicker{}} {
+		post.Meta().ID = id
+		if err := post.Read(); err == nil {
+			return post, nil
+		} else if errs.From(err).Status != http.StatusNotFound {
+			return nil, err
+		}
 	}
 
-	gallery := &Gallery{PostBase: PostBase{Base: Base{ID: id}}}
-	if status, err := gallery.Read(); err == nil {
-		return gallery, status, nil
-	} else if status != http.StatusNotFound {
-		return gallery, status, err
+	return nil, errs.NotFound(gorm.ErrRecordNotFound)
+}
+
+// PeekPost finds a Post across Post models without counting it as a read.
+func PeekPost(id uuid.UUID) (Post, error) {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		post := p.table().(Post)
+		if err := db.DB.Set("gorm:auto_preload", true).Where("id = ?", id).First(post).Error; err == nil {
+			return post, nil
+		} else if !gorm.IsRecordNotFoundError(err) {
+			return nil, errs.Internal(err)
+		}
+	}
+
+	return nil, errs.NotFound(gorm.ErrRecordNotFound)
+}
+
+// ReadRelatedCandidates fetches up to limit released Posts of each pattern
+// that share a marker, the section or the creator of pb, newest first
+func ReadRelatedCandidates(pb *PostBase, limit int) ([]Post, error) {
+	conds, args := []string{"creator = ?"}, []interface{}{pb.Creator}
+	if pb.Section != "" {
+		conds, args = append(conds, "section = ?"), append(args, pb.Section)
+	}
+	if len(pb.Markers) > 0 {
+		conds, args = append(conds, "markers && ?::varchar[]"), append(args, pq.StringArray(pb.Markers))
+	}
+
+	query := db.DB.Where("release = ? AND id <> ?", true, pb.ID).
+		Where(strings.Join(conds, " OR "), args...).
+		Order("released_at desc").
+		Limit(limit)
+	return findPosts(query)
+}
+
+// findPosts runs query against every Post model
+func findPosts(query *gorm.DB) ([]Post, error) {
+	posts := []Post{}
+	articles, galleries, flickers := []Article{}, []Gallery{}, []Flicker{}
+	if err := query.Find(&articles).Error; err != nil {
+		return posts, dbError(err)
+	}
+	if err := query.Find(&galleries).Error; err != nil {
+		return posts, dbError(err)
+	}
+	if err := query.Find(&flickers).Error; err != nil {
+		return posts, dbError(err)
 	}
 
-	flicker := &Flicker{PostBase: PostBase{Base: Base{ID: id}}}
-	if status, err := flicker.Read(); err == nil {
-		return flicker, status, nil
-	} else if status != http.StatusNotFound {
-		return flicker, status, nil
+	for i := range articles {
+		posts = append(posts, &articles[i])
+	}
+	for i := range galleries {
+		posts = append(posts, &galleries[i])
+	}
+	for i := range flickers {
+		posts = append(posts, &flickers[i])
 	}
 
-	status := http.StatusNotFound
-	return nil, status, errors.New(http.StatusText(status))
+	return posts, nil
 }
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditUserErase records the erasure of a User's personal data
+const AuditUserErase AuditAction = "user.erase"
+
+// MediaRef is the address of media a Post shows.
+// yap takes no uploads and hosts no media, so exports refer to it rather
+// than copy it; fetching the files themselves is out of scope.
+type MediaRef struct {
+	Post uuid.UUID `json:"post"`
+	URL  string    `json:"url"`
+}
+
+// UserExport is everything yap holds about a User, including what they deleted.
+// Media holds addresses only, as yap keeps no media files of its own.
+type UserExport struct {
+	Profile    User          `json:"profile"`
+	Articles   []Article     `json:"articles"`
+	Galleries  []Gallery     `json:"galleries"`
+	Flickers   []Flicker     `json:"flickers"`
+	Reactions  []Reaction    `json:"reactions"`
+	Follows    []Follow      `json:"follows"`
+	Lists      []ReadingList `json:"lists"`
+	Media      []MediaRef    `json:"media"`
+	Identities []Identity    `json:"identities"`
+}
+
+// ExportUser gathers everything yap holds about the User with id
+func ExportUser(id uuid.UUID) (UserExport, error) {
+	x := UserExport{}
+	if err := db.DB.First(&x.Profile, "id = ?", id).Error; err != nil {
+		return x, dbError(err)
+	}
+	x.Profile.Pass = ""
+
+	mine := db.DB.Unscoped().Order("created_at")
+	for _, q := range []struct {
+		dest   interface{}
+		column string
+	}{
+		{&x.Articles, "creator"}, {&x.Galleries, "creator"}, {&x.Flickers, "creator"},
+		{&x.Reactions, `"user"`}, {&x.Follows, `"user"`}, {&x.Identities, `"user"`},
+	} {
+		if err := mine.Where(q.column+" = ?", id).Find(q.dest).Error; err != nil {
+			return x, errs.Internal(err)
+		}
+	}
+	if err := db.DB.Where(&ReadingList{User: id}).Order("created_at").Find(&x.Lists).Error; err != nil {
+		return x, errs.Internal(err)
+	}
+	for i := range x.Lists {
+		if err := db.DB.Where(&ReadingItem{List: x.Lists[i].ID}).Order("position").Find(&x.Lists[i].Items).Error; err != nil {
+			return x, errs.Internal(err)
+		}
+	}
+
+	media := func(post uuid.UUID, urls ...string) {
+		for _, url := range urls {
+			if url != "" {
+				x.Media = append(x.Media, MediaRef{Post: post, URL: url})
+			}
+		}
+	}
+	for _, a := range x.Articles {
+		media(a.ID, a.Overlay)
+	}
+	for _, g := range x.Galleries {
+		media(g.ID, append([]string{g.Overlay}, g.Content...)...)
+	}
+	for _, f := range x.Flickers {
+		media(f.ID, f.Overlay, f.Content)
+	}
+
+	return x, nil
+}
+
+// Erase removes the personal data of a User on behalf of by.
+// Their Posts and Reactions stay where they are, so threads keep their
+// shape, but no longer name anyone; their lists, follows, notifications and
+// webhooks go, and so does the User. The audit log keeps what it recorded
+// before, but forgets the addresses and agents the User acted from.
+func (u *User) Erase(by Actor) error {
+	tx := db.DB.Begin()
+	fail := func(err error) error {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+
+	if err := eraseUserData(tx, []uuid.UUID{u.ID}); err != nil {
+		return fail(err)
+	}
+
+	for v, column := range userOwned() {
+		if err := tx.Unscoped().Model(v).Where(column+" = ?", u.ID).UpdateColumn(strings.Trim(column, `"`), uuid.Nil).Error; err != nil {
+			return fail(err)
+		}
+	}
+	for v, column := range map[interface{}]string{&Series{}: "creator", &PostAuthor{}: "inviter", &Notification{}: "actor", &PostTransition{}: "actor"} {
+		if err := tx.Unscoped().Model(v).Where(column+" = ?", u.ID).UpdateColumn(column, uuid.Nil).Error; err != nil {
+			return fail(err)
+		}
+	}
+
+	res := tx.Unscoped().Delete(u)
+	if err := deleteError(res); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := audit(tx, by, AuditUserErase, "user", u.ID, nil, nil); err != nil {
+		return fail(err)
+	}
+	if err := redactAuditLog(tx, u.ID); err != nil {
+		return fail(err)
+	}
+
+	return dbError(tx.Commit().Error)
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestUser_Erase(t *testing.T) {
+	defer useTestDB(t, &User{}, &Article{}, &Gallery{}, &Flicker{}, &Reaction{}, &Webhook{}, &WebhookDelivery{}, &Notification{}, &NotificationPreference{}, &Follow{}, &ReadingList{}, &ReadingItem{}, &Series{}, &PostAuthor{}, &PostTransition{}, &AuditEntry{}, &RecoveryCode{}, &Identity{}, &AccessToken{}, &Session{})()
+	user := User{Name: "Ada", Mail: "ada@example.com"}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	by := Actor{User: user.ID, IP: "192.0.2.1", Agent: "curl"}
+	if err := user.Assign(UserKeeper, by); err != nil {
+		t.Fatal(err)
+	}
+	hook := Webhook{URL: "https://example.com/hook", Creator: user.ID}
+	if err := hook.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.Create(&WebhookDelivery{Hook: hook.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := user.Erase(by); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+
+	var hooks, deliveries int
+	db.DB.Unscoped().Model(&Webhook{}).Count(&hooks)
+	db.DB.Unscoped().Model(&WebhookDelivery{}).Count(&deliveries)
+	if hooks != 0 || deliveries != 0 {
+		t.Errorf("Erase() left %d webhooks and %d deliveries, want none", hooks, deliveries)
+	}
+
+	entries, err := ReadAuditLog(AuditFilter{Actor: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("Erase() dropped the audit log")
+	}
+	for _, e := range entries {
+		if e.IP != "" || e.Agent != "" {
+			t.Errorf("Erase() kept audit entry %v from %q with %q", e.Action, e.IP, e.Agent)
+		}
+	}
+	if uuid.Equal(entries[0].Actor, uuid.Nil) {
+		t.Errorf("Erase() forgot the actor of the audit log")
+	}
+}
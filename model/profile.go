@@ -0,0 +1,151 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/slug"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ProfileField is a part of a Profile its User may hide
+type ProfileField string
+
+// ProfileFields a User may hide. Their handle and name are always public.
+const (
+	ProfileLife     ProfileField = "life"
+	ProfileAvatar   ProfileField = "avatar"
+	ProfileLinks    ProfileField = "links"
+	ProfileLocation ProfileField = "location"
+)
+
+// Bounds on the length of a handle
+const (
+	handleMin = 3
+	handleMax = 30
+)
+
+// Profile is what anyone may see of a User.
+// It never holds their mail, role or the fields they hid.
+type Profile struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Handle    string        `json:"handle"`
+	Name      string        `json:"name"`
+	Avatar    string        `json:"avatar,omitempty"`
+	Life      string        `json:"life,omitempty"`
+	Links     []string      `json:"links,omitempty"`
+	Location  string        `json:"location,omitempty"`
+	Follows   *FollowCounts `json:"follows,omitempty"`
+}
+
+// Shows reports whether the User lets anyone see f
+func (u *User) Shows(f ProfileField) bool {
+	for _, h := range u.Hidden {
+		if ProfileField(h) == f {
+			return false
+		}
+	}
+	return true
+}
+
+// Profile is the public face of a User
+func (u *User) Profile() Profile {
+	p := Profile{ID: u.ID, CreatedAt: u.CreatedAt, Handle: u.Handle, Name: u.Name, Follows: u.Follows}
+	if u.Shows(ProfileAvatar) {
+		p.Avatar = u.Avatar
+	}
+	if u.Shows(ProfileLife) {
+		p.Life = u.Life
+	}
+	if u.Shows(ProfileLinks) {
+		p.Links = append([]string{}, u.Links...)
+	}
+	if u.Shows(ProfileLocation) {
+		p.Location = u.Location
+	}
+	return p
+}
+
+// ReadUserByHandle fetches the User with handle, ignoring case
+func ReadUserByHandle(handle string) (User, error) {
+	user := User{}
+	err := db.DB.Where("handle = ?", strings.ToLower(handle)).First(&user).Error
+	return user, dbError(err)
+}
+
+// handleTaken reports whether a User other than id, trashed or not, has handle h
+func handleTaken(h string, id uuid.UUID) (bool, error) {
+	var count int
+	if err := db.DB.Unscoped().Model(&User{}).Where("handle = ? AND id <> ?", h, id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkHandle lowercases the handle a User asked for and fails if it is taken
+func (u *User) checkHandle() error {
+	u.Handle = strings.ToLower(u.Handle)
+	taken, err := handleTaken(u.Handle, u.ID)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	if taken {
+		return errs.New(http.StatusConflict, errs.CodeHandleTaken, "This handle is already taken.").
+			WithField("handle", string(errs.CodeHandleTaken), "")
+	}
+	return nil
+}
+
+// uniqueHandle builds an unused handle for the User id from their name
+func uniqueHandle(name string, id uuid.UUID) (string, error) {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case r == '-':
+			return '_'
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, slug.Make(name))
+	if len(base) > handleMax-4 {
+		base = strings.TrimRight(base[:handleMax-4], "_")
+	}
+	if len(base) < handleMin {
+		base = "user"
+	}
+
+	h := base
+	for n := 2; ; n++ {
+		taken, err := handleTaken(h, id)
+		if err != nil {
+			return "", err
+		} else if !taken {
+			return h, nil
+		}
+		h = fmt.Sprintf("%s_%d", base, n)
+	}
+}
+
+// backfillHandles gives a handle to every User created before handles existed
+func backfillHandles() error {
+	users := []User{}
+	if err := db.DB.Unscoped().Select("id, name").Where("handle = '' OR handle IS NULL").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		h, err := uniqueHandle(u.Name, u.ID)
+		if err != nil {
+			return err
+		}
+		if err := db.DB.Unscoped().Model(&u).UpdateColumn("handle", h).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,8 +4,9 @@ import (
 	"net/http"
 
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/render"
 
-	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -22,69 +23,76 @@ const (
 // Reaction represents a User action on a Post
 type Reaction struct {
 	Base
-	Type ReactionType `json:"type"`
-	User uuid.UUID    `gorm:"type:uuid" json:"user"`
-	Item uuid.UUID    `gorm:"type:uuid" json:"item"`
-	Site string       `json:"site"`
-	Text string       `json:"text"`
+	Type     ReactionType `json:"type"`
+	User     uuid.UUID    `gorm:"type:uuid" json:"user"`
+	Item     uuid.UUID    `gorm:"type:uuid" json:"item"`
+	Site     string       `json:"site"`
+	Text     string       `json:"text"`
+	TextHTML string       `json:"text_html,omitempty" sql:"-"`
+}
+
+// AfterFind renders a Reaction after it is loaded
+func (r *Reaction) AfterFind() error {
+	r.TextHTML = render.HTML(r.Text, render.FormatMarkdown)
+	return nil
+}
+
+// AfterSave renders a Reaction after it is written
+func (r *Reaction) AfterSave() error {
+	r.TextHTML = render.HTML(r.Text, render.FormatMarkdown)
+	return nil
 }
 
 // Create makes new reactions
-func (r *Reaction) Create() (int, error) {
+func (r *Reaction) Create() error {
 	if r.Type != ReactionComment {
 		r.Text = ""
 	}
 
 	if err := db.DB.Create(r).Error; err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
-	return http.StatusCreated, nil
+	return nil
 }
 
 // Read returns an existing reaction
-func (r *Reaction) Read() (int, error) {
-	if err := db.DB.First(r).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	}
-
-	return http.StatusOK, nil
+func (r *Reaction) Read() error {
+	return dbError(db.DB.First(r).Error)
 }
 
 // Update edits reaction text
-func (r *Reaction) Update() (int, error) {
+func (r *Reaction) Update() error {
 	if r.Type != ReactionComment {
-		return http.StatusMethodNotAllowed, nil
-	}
-
-	err := db.DB.Model(r).Updates(Reaction{Text: r.Text}).Error
-	if gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+		return errs.New(http.StatusUnprocessableEntity, errs.CodeNotEditable, "Only comments can be edited.")
 	}
 
-	return http.StatusAccepted, nil
+	return dbError(db.DB.Model(r).Updates(Reaction{Text: r.Text}).Error)
 }
 
-// Delete removes existing reactions
-func (r *Reaction) Delete() (int, error) {
-	db := db.DB.Delete(r)
-	if num, err := db.RowsAffected, db.Error; num == 0 {
-		return http.StatusNotFound, gorm.ErrRecordNotFound
-	} else if err != nil {
-		return http.StatusInternalServerError, nil
+// Delete removes an existing Reaction on behalf of by
+func (r *Reaction) Delete(by Actor) error {
+	tx := db.DB.Begin()
+	if err := deleteError(tx.Delete(r)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	before := map[string]interface{}{"type": r.Type, "user": r.User, "item": r.Item, "text": r.Text}
+	if err := audit(tx, by, AuditReactionDelete, "reaction", r.ID, before, nil); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
 	}
 
-	return http.StatusAccepted, nil
+	return dbError(tx.Commit().Error)
 }
 
 // ReadAllReactions fetches all Reactions
-func ReadAllReactions() ([]Reaction, int, error) {
+func ReadAllReactions() ([]Reaction, error) {
 	reactions := []Reaction{}
-	if err := db.DB.Find(&reactions).Error; gorm.IsRecordNotFoundError(err) {
-		return reactions, http.StatusNotFound, err
+	if err := db.DB.Find(&reactions).Error; err != nil {
+		return reactions, dbError(err)
 	}
 
-	return reactions, http.StatusOK, nil
+	return reactions, nil
 }
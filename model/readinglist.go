@@ -0,0 +1,241 @@
+package model
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ReadingList is a User's named, ordered collection of Posts.
+// Every User has one private Bookmarks list; others may be shared by link.
+type ReadingList struct {
+	Base
+	User      uuid.UUID     `json:"user" gorm:"type:uuid;index"`
+	Name      string        `json:"name"`
+	Bookmarks bool          `json:"bookmarks"`
+	Share     string        `json:"share,omitempty" gorm:"index"`
+	Items     []ReadingItem `json:"items,omitempty" sql:"-"`
+}
+
+// ItemState is whether a ReadingItem's Post can still be read
+type ItemState string
+
+// ItemStates of saved Posts
+const (
+	ItemReleased  ItemState = "released"
+	ItemRetracted ItemState = "retracted"
+	ItemDeleted   ItemState = "deleted"
+)
+
+// ReadingItem is a Post saved to a ReadingList.
+// Content is only filled in while the Post can be read.
+type ReadingItem struct {
+	Base
+	List     uuid.UUID `json:"list" gorm:"type:uuid;unique_index:idx_item_list_post"`
+	Post     uuid.UUID `json:"post" gorm:"type:uuid;unique_index:idx_item_list_post"`
+	Position int       `json:"position"`
+	State    ItemState `json:"state" sql:"-"`
+	Content  Post      `json:"content,omitempty" sql:"-"`
+}
+
+// Create makes a ReadingList
+func (l *ReadingList) Create() error {
+	return dbError(db.DB.Create(l).Error)
+}
+
+// Read fetches a ReadingList and its items
+func (l *ReadingList) Read() error {
+	if err := db.DB.Where(&ReadingList{Base: Base{ID: l.ID}}).First(l).Error; err != nil {
+		return dbError(err)
+	}
+
+	return l.readItems()
+}
+
+// Update modifies a ReadingList
+func (l *ReadingList) Update() error {
+	return dbError(db.DB.Model(l).Updates(map[string]interface{}{"name": l.Name, "share": l.Share}).Error)
+}
+
+// Delete removes a ReadingList and its items
+func (l *ReadingList) Delete() error {
+	if err := db.DB.Unscoped().Where(&ReadingItem{List: l.ID}).Delete(&ReadingItem{}).Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	return deleteError(db.DB.Delete(l))
+}
+
+// readItems fetches the items of l in order and checks on their Posts
+func (l *ReadingList) readItems() error {
+	l.Items = []ReadingItem{}
+	if err := db.DB.Where(&ReadingItem{List: l.ID}).Order("position, created_at").Find(&l.Items).Error; err != nil {
+		return dbError(err)
+	}
+
+	for i := range l.Items {
+		if err := l.Items[i].resolve(l.User); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve sets the State of an item, and its Content if owner may read it.
+// Anyone invited to work on an unreleased Post may still read it.
+func (it *ReadingItem) resolve(owner uuid.UUID) error {
+	post, err := PeekPost(it.Post)
+	if errs.From(err).Status == http.StatusNotFound {
+		it.State = ItemDeleted
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	meta := post.Meta()
+	if meta.Release {
+		it.State, it.Content = ItemReleased, post
+		return nil
+	}
+
+	it.State = ItemRetracted
+	role, err := AuthorRoleOf(meta, owner)
+	if err != nil {
+		return err
+	} else if role != "" {
+		it.Content = post
+	}
+	return nil
+}
+
+// AddItem saves a Post to the end of l, or returns the item if it is already saved
+func (l *ReadingList) AddItem(post uuid.UUID) (ReadingItem, error) {
+	item := ReadingItem{}
+	tx := db.DB.Begin()
+
+	// Touching the list locks it, so adds to it take turns picking a position
+	if err := tx.Model(&ReadingList{}).Where("id = ?", l.ID).UpdateColumn("updated_at", time.Now().UTC()).Error; err != nil {
+		tx.Rollback()
+		return item, errs.Internal(err)
+	}
+
+	err := tx.Where(&ReadingItem{List: l.ID, Post: post}).First(&item).Error
+	if err == nil {
+		tx.Rollback()
+		return item, item.resolve(l.User)
+	} else if !gorm.IsRecordNotFoundError(err) {
+		tx.Rollback()
+		return item, errs.Internal(err)
+	}
+
+	var last struct{ Position int }
+	if err := tx.Model(&ReadingItem{}).Select("COALESCE(MAX(position), 0) AS position").Where(&ReadingItem{List: l.ID}).Scan(&last).Error; err != nil {
+		tx.Rollback()
+		return item, errs.Internal(err)
+	}
+
+	item = ReadingItem{List: l.ID, Post: post, Position: last.Position + 1}
+	if err := tx.Create(&item).Error; err != nil {
+		tx.Rollback()
+		return item, errs.Internal(err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return item, errs.Internal(err)
+	}
+
+	return item, item.resolve(l.User)
+}
+
+// RemoveItem removes an item from l
+func (l *ReadingList) RemoveItem(item uuid.UUID) error {
+	return deleteError(db.DB.Unscoped().Where(&ReadingItem{Base: Base{ID: item}, List: l.ID}).Delete(&ReadingItem{}))
+}
+
+// Reorder moves the items of l into the order of order,
+// which must name every item exactly once.
+func (l *ReadingList) Reorder(order []uuid.UUID) error {
+	if err := l.readItems(); err != nil {
+		return err
+	}
+
+	invalid := errs.Invalid(errs.Field{Name: "items", Code: errs.FieldInvalid, Message: "Must list every item in the list exactly once."})
+	if len(order) != len(l.Items) {
+		return invalid
+	}
+
+	pending := map[uuid.UUID]bool{}
+	for _, it := range l.Items {
+		pending[it.ID] = true
+	}
+	for _, id := range order {
+		if !pending[id] {
+			return invalid
+		}
+		delete(pending, id)
+	}
+
+	tx := db.DB.Begin()
+	for i, id := range order {
+		if err := tx.Model(&ReadingItem{}).Where("id = ?", id).UpdateColumn("position", i+1).Error; err != nil {
+			tx.Rollback()
+			return errs.Internal(err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	return l.readItems()
+}
+
+// ReadUserLists fetches a User's ReadingLists without their items
+func ReadUserLists(user uuid.UUID) ([]ReadingList, error) {
+	lists := []ReadingList{}
+	if err := db.DB.Where(&ReadingList{User: user}).Order("bookmarks desc, name").Find(&lists).Error; err != nil {
+		return lists, dbError(err)
+	}
+
+	return lists, nil
+}
+
+// ReadSharedList fetches the ReadingList shared with token, leaving out
+// items that can no longer be read.
+func ReadSharedList(token string) (ReadingList, error) {
+	list := ReadingList{}
+	if token == "" {
+		return list, errs.NotFound(nil)
+	}
+	if err := db.DB.Where(&ReadingList{Share: token}).First(&list).Error; err != nil {
+		return list, dbError(err)
+	}
+	if err := list.readItems(); err != nil {
+		return list, err
+	}
+
+	items := []ReadingItem{}
+	for _, it := range list.Items {
+		if it.State == ItemReleased {
+			items = append(items, it)
+		}
+	}
+	list.Items = items
+	return list, nil
+}
+
+// UserBookmarks fetches a User's Bookmarks list, making it on first use
+func UserBookmarks(user uuid.UUID) (ReadingList, error) {
+	list := ReadingList{}
+	err := db.DB.Where(&ReadingList{User: user, Bookmarks: true}).
+		Attrs(ReadingList{Name: "Bookmarks"}).
+		FirstOrCreate(&list).Error
+	if err != nil {
+		return list, dbError(err)
+	}
+
+	return list, list.readItems()
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// listOf makes a ReadingList shared as "token" holding a new Article for
+// each of release, in order
+func listOf(t *testing.T, release ...bool) (ReadingList, []ReadingItem) {
+	l := ReadingList{User: uuid.NewV4(), Name: "Later", Share: "token"}
+	if err := l.Create(); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	items := make([]ReadingItem, len(release))
+	for i, r := range release {
+		a := Article{PostBase: PostBase{Subject: "Saved", Pattern: articlePost, Release: r}}
+		if err := db.DB.Create(&a).Error; err != nil {
+			t.Fatal(err)
+		}
+		item, err := l.AddItem(a.ID)
+		if err != nil {
+			t.Fatalf("AddItem() error = %v", err)
+		}
+		items[i] = item
+	}
+	return l, items
+}
+
+func TestReadingList_AddItem(t *testing.T) {
+	defer useTestDB(t, &ReadingList{}, &ReadingItem{}, &Article{}, &Gallery{}, &Flicker{}, &PostAuthor{})()
+	l, items := listOf(t, true, true)
+
+	for i, it := range items {
+		if it.Position != i+1 {
+			t.Errorf("AddItem() position = %v, want %v", it.Position, i+1)
+		}
+	}
+
+	again, err := l.AddItem(items[0].Post)
+	if err != nil || !uuid.Equal(again.ID, items[0].ID) {
+		t.Errorf("AddItem() again = %v, %v, want %v", again.ID, err, items[0].ID)
+	}
+	var count int
+	db.DB.Model(&ReadingItem{}).Count(&count)
+	if count != len(items) {
+		t.Errorf("AddItem() again left %d items, want %d", count, len(items))
+	}
+}
+
+func TestReadingList_Reorder(t *testing.T) {
+	defer useTestDB(t, &ReadingList{}, &ReadingItem{}, &Article{}, &Gallery{}, &Flicker{}, &PostAuthor{})()
+	l, items := listOf(t, true, true, true)
+	a, b, c := items[0].ID, items[1].ID, items[2].ID
+
+	for name, order := range map[string][]uuid.UUID{
+		"missing":   {a, b},
+		"duplicate": {a, b, b},
+		"stranger":  {a, b, uuid.NewV4()},
+		"extra":     {a, b, c, c},
+	} {
+		if err := l.Reorder(order); errs.From(err).Status != http.StatusBadRequest {
+			t.Errorf("Reorder() %s error = %v, want invalid", name, err)
+		}
+	}
+
+	if err := l.Reorder([]uuid.UUID{c, a, b}); err != nil {
+		t.Fatalf("Reorder() error = %v", err)
+	}
+	if len(l.Items) != 3 || !uuid.Equal(l.Items[0].ID, c) || !uuid.Equal(l.Items[1].ID, a) || !uuid.Equal(l.Items[2].ID, b) {
+		t.Errorf("Reorder() items = %v, want %v, %v, %v", l.Items, c, a, b)
+	}
+}
+
+func TestReadSharedList(t *testing.T) {
+	defer useTestDB(t, &ReadingList{}, &ReadingItem{}, &Article{}, &Gallery{}, &Flicker{}, &PostAuthor{})()
+	_, items := listOf(t, true, false, true)
+	if err := db.DB.Unscoped().Delete(&Article{}, "id = ?", items[2].Post).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	shared, err := ReadSharedList("token")
+	if err != nil {
+		t.Fatalf("ReadSharedList() error = %v", err)
+	}
+	if len(shared.Items) != 1 || !uuid.Equal(shared.Items[0].ID, items[0].ID) {
+		t.Errorf("ReadSharedList() items = %v, want only %v", shared.Items, items[0].ID)
+	}
+
+	if _, err := ReadSharedList(""); errs.From(err).Status != http.StatusNotFound {
+		t.Errorf("ReadSharedList() without a token error = %v, want not found", err)
+	}
+}
+
+func TestReadingItem_resolve(t *testing.T) {
+	defer useTestDB(t, &Article{}, &Gallery{}, &Flicker{}, &PostAuthor{})()
+	creator, reviewer, invited, stranger := uuid.NewV4(), uuid.NewV4(), uuid.NewV4(), uuid.NewV4()
+	draft := Article{PostBase: PostBase{Subject: "Draft", Pattern: articlePost, Creator: creator}}
+	if err := db.DB.Create(&draft).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, pa := range []PostAuthor{
+		{Post: draft.ID, User: reviewer, Role: AuthorReviewer, Accepted: &now},
+		{Post: draft.ID, User: invited, Role: AuthorCoauthor},
+	} {
+		if err := db.DB.Create(&pa).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		owner   uuid.UUID
+		post    uuid.UUID
+		state   ItemState
+		content bool
+	}{
+		{name: "Creator Test", owner: creator, post: draft.ID, state: ItemRetracted, content: true},
+		{name: "Reviewer Test", owner: reviewer, post: draft.ID, state: ItemRetracted, content: true},
+		{name: "Pending Invite Test", owner: invited, post: draft.ID, state: ItemRetracted},
+		{name: "Stranger Test", owner: stranger, post: draft.ID, state: ItemRetracted},
+		{name: "Deleted Test", owner: creator, post: uuid.NewV4(), state: ItemDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := ReadingItem{Post: tt.post}
+			if err := it.resolve(tt.owner); err != nil {
+				t.Fatalf("resolve() error = %v", err)
+			}
+			if it.State != tt.state || (it.Content != nil) != tt.content {
+				t.Errorf("resolve() = %v with content %v, want %v with content %v", it.State, it.Content != nil, tt.state, tt.content)
+			}
+		})
+	}
+}
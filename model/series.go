@@ -0,0 +1,219 @@
+package model
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Series is an ordered run of Posts of any pattern, such as a multi-part tutorial
+type Series struct {
+	Base
+	Name    string        `json:"name"`
+	Summary string        `json:"summary"`
+	Creator uuid.UUID     `json:"creator" gorm:"type:uuid;index"`
+	Entries []SeriesEntry `json:"entries,omitempty" sql:"-"`
+}
+
+// SeriesEntry places a Post in a Series. A Post belongs to at most one Series.
+// Pattern, Subject, Slug and Release describe the Post when read.
+type SeriesEntry struct {
+	Base
+	Series   uuid.UUID   `json:"series" gorm:"type:uuid;index"`
+	Post     uuid.UUID   `json:"post" gorm:"type:uuid;unique_index"`
+	Position int         `json:"position"`
+	Pattern  postPattern `json:"pattern" sql:"-"`
+	Subject  string      `json:"subject" sql:"-"`
+	Slug     string      `json:"slug" sql:"-"`
+	Release  bool        `json:"release" sql:"-"`
+}
+
+// SeriesNav places a Post within its Series, for prev/next navigation
+type SeriesNav struct {
+	ID       uuid.UUID    `json:"id"`
+	Name     string       `json:"name"`
+	Position int          `json:"position"`
+	Total    int          `json:"total"`
+	Prev     *SeriesEntry `json:"prev,omitempty"`
+	Next     *SeriesEntry `json:"next,omitempty"`
+}
+
+// Create makes a Series
+func (s *Series) Create() error {
+	return dbError(db.DB.Create(s).Error)
+}
+
+// Read fetches a Series and its entries.
+// When public is set, entries for unreleased Posts are left out.
+func (s *Series) Read(public bool) error {
+	if err := db.DB.Where(&Series{Base: Base{ID: s.ID}}).First(s).Error; err != nil {
+		return dbError(err)
+	}
+
+	return s.readEntries(public)
+}
+
+// Update modifies a Series
+func (s *Series) Update() error {
+	return dbError(db.DB.Model(s).Updates(map[string]interface{}{"name": s.Name, "summary": s.Summary}).Error)
+}
+
+// Delete removes a Series, leaving its Posts alone
+func (s *Series) Delete() error {
+	if err := db.DB.Unscoped().Where(&SeriesEntry{Series: s.ID}).Delete(&SeriesEntry{}).Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	return deleteError(db.DB.Delete(s))
+}
+
+// readEntries fetches the entries of s in order, dropping those whose Post is gone
+func (s *Series) readEntries(public bool) error {
+	entries := []SeriesEntry{}
+	if err := db.DB.Where(&SeriesEntry{Series: s.ID}).Order("position, created_at").Find(&entries).Error; err != nil {
+		return dbError(err)
+	}
+
+	s.Entries = []SeriesEntry{}
+	for _, e := range entries {
+		post, err := PeekPost(e.Post)
+		if errs.From(err).Status == http.StatusNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		meta := post.Meta()
+		if public && !meta.Release {
+			continue
+		}
+
+		e.Pattern, e.Subject, e.Slug, e.Release = meta.Pattern, meta.Subject, meta.Slug, meta.Release
+		s.Entries = append(s.Entries, e)
+	}
+
+	return nil
+}
+
+// AddPost appends a Post to s. A Post already in another Series is a conflict.
+func (s *Series) AddPost(post uuid.UUID) error {
+	entry := SeriesEntry{}
+	err := db.DB.Where(&SeriesEntry{Post: post}).First(&entry).Error
+	if err == nil {
+		if uuid.Equal(entry.Series, s.ID) {
+			return nil
+		}
+		return errs.New(http.StatusConflict, errs.CodeConflict, "This post is already part of another series.")
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return errs.Internal(err)
+	}
+
+	var last struct{ Position int }
+	if err := db.DB.Model(&SeriesEntry{}).Select("COALESCE(MAX(position), 0) AS position").Where(&SeriesEntry{Series: s.ID}).Scan(&last).Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	entry = SeriesEntry{Series: s.ID, Post: post, Position: last.Position + 1}
+	return dbError(db.DB.Create(&entry).Error)
+}
+
+// RemovePost takes a Post out of s
+func (s *Series) RemovePost(post uuid.UUID) error {
+	return deleteError(db.DB.Unscoped().Where(&SeriesEntry{Series: s.ID, Post: post}).Delete(&SeriesEntry{}))
+}
+
+// Reorder moves the Posts of s into the order of posts,
+// which must name every live Post in s exactly once.
+// Entries whose Post is in the trash keep their order after the rest.
+func (s *Series) Reorder(posts []uuid.UUID) error {
+	entries := []SeriesEntry{}
+	if err := db.DB.Where(&SeriesEntry{Series: s.ID}).Order("position, created_at").Find(&entries).Error; err != nil {
+		return dbError(err)
+	}
+	if err := s.readEntries(false); err != nil {
+		return err
+	}
+
+	invalid := errs.Invalid(errs.Field{Name: "posts", Code: errs.FieldInvalid, Message: "Must list every post in the series exactly once."})
+	if len(posts) != len(s.Entries) {
+		return invalid
+	}
+
+	live, seen := map[uuid.UUID]bool{}, map[uuid.UUID]bool{}
+	for _, e := range s.Entries {
+		live[e.Post] = true
+	}
+	for _, id := range posts {
+		if !live[id] || seen[id] {
+			return invalid
+		}
+		seen[id] = true
+	}
+
+	order := append([]uuid.UUID{}, posts...)
+	for _, e := range entries {
+		if !live[e.Post] {
+			order = append(order, e.Post)
+		}
+	}
+
+	tx := db.DB.Begin()
+	for i, id := range order {
+		if err := tx.Model(&SeriesEntry{}).Where("series = ? AND post = ?", s.ID, id).UpdateColumn("position", i+1).Error; err != nil {
+			tx.Rollback()
+			return errs.Internal(err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	return s.readEntries(false)
+}
+
+// ReadAllSeries fetches every Series without its entries
+func ReadAllSeries() ([]Series, error) {
+	series := []Series{}
+	if err := db.DB.Order("created_at desc").Find(&series).Error; err != nil {
+		return series, dbError(err)
+	}
+
+	return series, nil
+}
+
+// NavigateSeries sets pb.Series when the Post is part of a Series.
+// When public is set, unreleased Posts are skipped over.
+func NavigateSeries(pb *PostBase, public bool) error {
+	entry := SeriesEntry{}
+	if err := db.DB.Where(&SeriesEntry{Post: pb.ID}).First(&entry).Error; gorm.IsRecordNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return errs.Internal(err)
+	}
+
+	s := Series{Base: Base{ID: entry.Series}}
+	if err := s.Read(public); err != nil {
+		return err
+	}
+
+	for i := range s.Entries {
+		if !uuid.Equal(s.Entries[i].Post, pb.ID) {
+			continue
+		}
+
+		nav := &SeriesNav{ID: s.ID, Name: s.Name, Position: i + 1, Total: len(s.Entries)}
+		if i > 0 {
+			nav.Prev = &s.Entries[i-1]
+		}
+		if i < len(s.Entries)-1 {
+			nav.Next = &s.Entries[i+1]
+		}
+		pb.Series = nav
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// seriesOf makes a Series holding a new Article for each of release, in order
+func seriesOf(t *testing.T, release ...bool) (Series, []Article) {
+	s := Series{Name: "Parts"}
+	if err := s.Create(); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	articles := make([]Article, len(release))
+	for i, r := range release {
+		articles[i] = Article{PostBase: PostBase{Subject: "Part", Pattern: articlePost, Release: r}}
+		if err := db.DB.Create(&articles[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AddPost(articles[i].ID); err != nil {
+			t.Fatalf("AddPost() error = %v", err)
+		}
+	}
+	return s, articles
+}
+
+func TestSeries_AddPost(t *testing.T) {
+	defer useTestDB(t, &Series{}, &SeriesEntry{}, &Article{}, &Gallery{}, &Flicker{})()
+	s, articles := seriesOf(t, true)
+
+	if err := s.AddPost(articles[0].ID); err != nil {
+		t.Errorf("AddPost() again error = %v, want nil", err)
+	}
+
+	other, _ := seriesOf(t)
+	if err := other.AddPost(articles[0].ID); errs.From(err).Status != http.StatusConflict {
+		t.Errorf("AddPost() to another series error = %v, want conflict", err)
+	}
+}
+
+func TestSeries_Reorder(t *testing.T) {
+	defer useTestDB(t, &Series{}, &SeriesEntry{}, &Article{}, &Gallery{}, &Flicker{})()
+	s, articles := seriesOf(t, true, true, true)
+	a, b, c := articles[0].ID, articles[1].ID, articles[2].ID
+
+	for name, posts := range map[string][]uuid.UUID{
+		"missing":   {a, b},
+		"duplicate": {a, b, b},
+		"stranger":  {a, b, uuid.NewV4()},
+	} {
+		if err := s.Reorder(posts); errs.From(err).Status != http.StatusBadRequest {
+			t.Errorf("Reorder() %s error = %v, want invalid", name, err)
+		}
+	}
+
+	if err := db.DB.Delete(&articles[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reorder([]uuid.UUID{c, a}); err != nil {
+		t.Fatalf("Reorder() without trashed post error = %v", err)
+	}
+	if len(s.Entries) != 2 || !uuid.Equal(s.Entries[0].Post, c) || !uuid.Equal(s.Entries[1].Post, a) {
+		t.Errorf("Reorder() entries = %v, want %v then %v", s.Entries, c, a)
+	}
+}
+
+func TestNavigateSeries(t *testing.T) {
+	defer useTestDB(t, &Series{}, &SeriesEntry{}, &Article{}, &Gallery{}, &Flicker{})()
+	_, articles := seriesOf(t, true, false, true)
+	first, hidden, last := articles[0].PostBase, articles[1].PostBase, articles[2].PostBase
+
+	if err := NavigateSeries(&first, false); err != nil {
+		t.Fatalf("NavigateSeries() error = %v", err)
+	}
+	if nav := first.Series; nav == nil || nav.Position != 1 || nav.Total != 3 || nav.Prev != nil || !uuid.Equal(nav.Next.Post, hidden.ID) {
+		t.Errorf("NavigateSeries() of first = %+v, want next %v", nav, hidden.ID)
+	}
+
+	if err := NavigateSeries(&last, true); err != nil {
+		t.Fatalf("NavigateSeries() error = %v", err)
+	}
+	if nav := last.Series; nav == nil || nav.Position != 2 || nav.Total != 2 || nav.Next != nil || !uuid.Equal(nav.Prev.Post, first.ID) {
+		t.Errorf("NavigateSeries() public of last = %+v, want prev %v", nav, first.ID)
+	}
+
+	lone := PostBase{Base: Base{ID: uuid.NewV4()}}
+	if err := NavigateSeries(&lone, true); err != nil || lone.Series != nil {
+		t.Errorf("NavigateSeries() outside a series = %+v, %v, want nil", lone.Series, err)
+	}
+}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditSessionsRevoke is the AuditAction of revoking another User's Sessions
+const AuditSessionsRevoke AuditAction = "user.revoke_sessions"
+
+// sessionTouch is how stale LastSeen may get before a request updates it
+const sessionTouch = time.Minute
+
+// Settings recording when a change to auth tokens was first deployed
+const (
+	// settingSessionsSince is when Sessions were introduced.
+	// Tokens issued before then carry no Session.
+	settingSessionsSince = "sessions_since"
+	// settingKeysSince is when tokens were first signed with a keyring.
+	// Tokens issued before then were signed with JWT_SECRET.
+	settingKeysSince = "keys_since"
+)
+
+// since caches the time recorded in a Setting
+type since struct {
+	sync.RWMutex
+	at time.Time
+}
+
+// sessionsSince and keysSince cache settingSessionsSince and settingKeysSince
+var sessionsSince, keysSince since
+
+// Session is one login of a User, named in the tokens it was issued with.
+// Deleting it revokes them.
+type Session struct {
+	Base
+	User     uuid.UUID `json:"user" gorm:"type:uuid;index"`
+	Device   string    `json:"device"`
+	Agent    string    `json:"agent"`
+	IP       string    `json:"ip"`
+	LastSeen time.Time `json:"last_seen_at"`
+	Expires  time.Time `json:"expires_at"`
+	Current  bool      `json:"current" sql:"-"`
+}
+
+// Create makes a Session
+func (s *Session) Create() error {
+	s.Device = deviceOf(s.Agent)
+	s.LastSeen = time.Now().UTC()
+	return dbError(db.DB.Create(s).Error)
+}
+
+// TouchSession checks that the Session with id of user is live, and
+// records that it was seen from ip
+func TouchSession(id, user uuid.UUID, ip string) error {
+	s := Session{}
+	err := db.DB.Where("id = ? AND \"user\" = ? AND expires > ?", id, user, time.Now()).First(&s).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return errs.New(http.StatusUnauthorized, errs.CodeUnauthorized, "This session was revoked or has expired. Log in again.")
+	} else if err != nil {
+		return errs.Internal(err)
+	}
+
+	now := time.Now().UTC()
+	if now.Sub(s.LastSeen) > sessionTouch || s.IP != ip {
+		return dbError(db.DB.Model(&s).UpdateColumns(map[string]interface{}{"last_seen": now, "ip": ip}).Error)
+	}
+	return nil
+}
+
+// ReadSessions lists the live Sessions of a User, most recently seen first
+func (u *User) ReadSessions() ([]Session, error) {
+	sessions := []Session{}
+	err := db.DB.Where(&Session{User: u.ID}).Where("expires > ?", time.Now()).Order("last_seen DESC").Find(&sessions).Error
+	if err != nil {
+		return sessions, errs.Internal(err)
+	}
+	return sessions, nil
+}
+
+// Delete revokes a Session of its User
+func (s *Session) Delete() error {
+	return deleteError(db.DB.Where(`"user" = ?`, s.User).Delete(s))
+}
+
+// RevokeSessions revokes every Session of a User except keep, and returns
+// how many it revoked. by is audited when they are not the User.
+func (u *User) RevokeSessions(keep uuid.UUID, by Actor) (int64, error) {
+	return u.revoke(keep, by, false)
+}
+
+// RevokeAccess is RevokeSessions that also revokes every AccessToken of the
+// User, as when their password changes.
+func (u *User) RevokeAccess(keep uuid.UUID, by Actor) (int64, error) {
+	return u.revoke(keep, by, true)
+}
+
+// revoke deletes the Sessions of a User but keep, and their AccessTokens
+// when tokens is set, in one transaction
+func (u *User) revoke(keep uuid.UUID, by Actor, tokens bool) (int64, error) {
+	tx := db.DB.Begin()
+	res := tx.Where(`"user" = ? AND id <> ?`, u.ID, keep).Delete(&Session{})
+	if res.Error != nil {
+		tx.Rollback()
+		return 0, errs.Internal(res.Error)
+	}
+
+	if tokens {
+		if err := tx.Where(`"user" = ?`, u.ID).Delete(&AccessToken{}).Error; err != nil {
+			tx.Rollback()
+			return 0, errs.Internal(err)
+		}
+	}
+
+	if !uuid.Equal(by.User, u.ID) {
+		if err := audit(tx, by, AuditSessionsRevoke, "user", u.ID, nil, map[string]int64{"revoked": res.RowsAffected}); err != nil {
+			tx.Rollback()
+			return 0, errs.Internal(err)
+		}
+	}
+	return res.RowsAffected, dbError(tx.Commit().Error)
+}
+
+// SessionsSince is when Sessions were introduced. Tokens issued before then
+// are accepted without one until they expire.
+func SessionsSince() time.Time {
+	return sessionsSince.get()
+}
+
+// KeysSince is when tokens were first signed with a keyring. It is recorded
+// once, so rotating or losing keys never moves it.
+func KeysSince() time.Time {
+	return keysSince.get()
+}
+
+// get returns the cached time
+func (s *since) get() time.Time {
+	s.RLock()
+	defer s.RUnlock()
+	return s.at
+}
+
+// load reads the Setting name into s, recording now there on first run
+func (s *since) load(name string) error {
+	setting := Setting{}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := db.DB.Where(Setting{Name: name}).Attrs(Setting{Value: now}).FirstOrCreate(&setting).Error; err != nil {
+		return err
+	}
+
+	at, err := time.Parse(time.RFC3339, setting.Value)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.at = at
+	s.Unlock()
+	return nil
+}
+
+// deviceOf sums up a user agent as a browser and platform, like "Firefox on Linux"
+func deviceOf(agent string) string {
+	first := func(names ...string) string {
+		for i := 0; i+1 < len(names); i += 2 {
+			if strings.Contains(agent, names[i]) {
+				return names[i+1]
+			}
+		}
+		return ""
+	}
+
+	browser := first("Edg/", "Edge", "OPR/", "Opera", "Firefox/", "Firefox", "Chrome/", "Chrome", "Safari/", "Safari", "curl/", "curl", "Go-http-client", "Go")
+	platform := first("Android", "Android", "iPhone", "iOS", "iPad", "iPadOS", "Windows", "Windows", "Mac OS X", "macOS", "CrOS", "ChromeOS", "Linux", "Linux")
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
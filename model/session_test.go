@@ -0,0 +1,76 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestSince_Load(t *testing.T) {
+	defer useTestDB(t, &Setting{})()
+	recorded := time.Now().Add(-30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	if err := db.DB.Create(&Setting{Name: settingKeysSince, Value: recorded.Format(time.RFC3339)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := since{}
+	if err := s.load(settingKeysSince); err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !s.get().Equal(recorded) {
+		t.Errorf("load() = %v, want the recorded %v", s.get(), recorded)
+	}
+
+	first := since{}
+	if err := first.load(settingSessionsSince); err != nil {
+		t.Fatalf("load() on first run error = %v", err)
+	}
+	again := since{}
+	if err := again.load(settingSessionsSince); err != nil || !again.get().Equal(first.get()) {
+		t.Errorf("load() again = %v, %v, want %v", again.get(), err, first.get())
+	}
+}
+
+func TestUser_RevokeAccess(t *testing.T) {
+	defer useTestDB(t, &Session{}, &AccessToken{}, &AuditEntry{})()
+	u, other := User{Base: Base{ID: uuid.NewV4()}}, User{Base: Base{ID: uuid.NewV4()}}
+	expires := time.Now().Add(time.Hour)
+
+	keep := Session{User: u.ID, Expires: expires}
+	for _, s := range []*Session{&keep, {User: u.ID, Expires: expires}, {User: other.ID, Expires: expires}} {
+		if err := s.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, owner := range []uuid.UUID{u.ID, other.ID} {
+		token := AccessToken{User: owner, Expires: expires}
+		if err := token.Create(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := u.RevokeSessions(keep.ID, Actor{User: u.ID}); err != nil || n != 1 {
+		t.Fatalf("RevokeSessions() = %v, %v, want 1", n, err)
+	}
+	if tokens, _ := u.ReadAccessTokens(); len(tokens) != 1 {
+		t.Errorf("RevokeSessions() left %v tokens, want 1", len(tokens))
+	}
+
+	if _, err := u.RevokeAccess(keep.ID, Actor{User: u.ID}); err != nil {
+		t.Fatalf("RevokeAccess() error = %v", err)
+	}
+	if tokens, _ := u.ReadAccessTokens(); len(tokens) != 0 {
+		t.Errorf("RevokeAccess() left %v tokens, want none", len(tokens))
+	}
+	if sessions, _ := u.ReadSessions(); len(sessions) != 1 || !uuid.Equal(sessions[0].ID, keep.ID) {
+		t.Errorf("RevokeAccess() left sessions %v, want only %v", sessions, keep.ID)
+	}
+	if tokens, _ := other.ReadAccessTokens(); len(tokens) != 1 {
+		t.Errorf("RevokeAccess() left another user %v tokens, want 1", len(tokens))
+	}
+	if sessions, _ := other.ReadSessions(); len(sessions) != 1 {
+		t.Errorf("RevokeAccess() left another user %v sessions, want 1", len(sessions))
+	}
+}
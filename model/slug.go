@@ -0,0 +1,185 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/slug"
+	uuid "github.com/satori/go.uuid"
+)
+
+// PostSlug is a slug a Post used to have, kept so old links still resolve
+type PostSlug struct {
+	Base
+	Slug    string      `json:"slug" gorm:"index"`
+	Post    uuid.UUID   `json:"post" gorm:"type:uuid"`
+	Pattern postPattern `json:"pattern"`
+}
+
+// table returns an empty model for the Post type p
+func (p postPattern) table() interface{} {
+	switch p {
+	case articlePost:
+		return &Article{}
+	case galleryPost:
+		return &Gallery{}
+	default:
+		return &Flicker{}
+	}
+}
+
+// patternOf returns the postPattern of a Post
+func patternOf(post Post) postPattern {
+	switch post.(type) {
+	case *Article:
+		return articlePost
+	case *Gallery:
+		return galleryPost
+	default:
+		return flickerPost
+	}
+}
+
+// slugTaken reports whether s is in use by a Post of pattern p other than id
+func slugTaken(p postPattern, s string, id uuid.UUID) (bool, error) {
+	var count int
+	if err := db.DB.Unscoped().Model(p.table()).Where("slug = ? AND id <> ?", s, id).Count(&count).Error; err != nil {
+		return false, err
+	} else if count > 0 {
+		return true, nil
+	}
+
+	if err := db.DB.Model(&PostSlug{}).Where("slug = ? AND pattern = ? AND post <> ?", s, p, id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// uniqueSlug builds an unused slug for the Post id from its subject
+func uniqueSlug(p postPattern, subject string, id uuid.UUID) (string, error) {
+	base := slug.Make(subject)
+	if base == "" {
+		base = string(p)
+	}
+
+	s := base
+	for n := 2; ; n++ {
+		taken, err := slugTaken(p, s, id)
+		if err != nil {
+			return "", err
+		} else if !taken {
+			return s, nil
+		}
+		s = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// setSlug picks the slug for a PostBase being created or updated.
+// Renamed posts get a new slug and their previous one is kept in the history.
+func (pb *PostBase) setSlug(p postPattern) error {
+	if uuid.Equal(pb.ID, uuid.Nil) {
+		s, err := uniqueSlug(p, pb.Subject, uuid.Nil)
+		pb.Slug = s
+		return err
+	}
+
+	current := PostBase{}
+	err := db.DB.Model(p.table()).Select("subject, slug").Where("id = ?", pb.ID).Row().Scan(&current.Subject, &current.Slug)
+	if err == sql.ErrNoRows {
+		return gorm.ErrRecordNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if pb.Subject == "" || (pb.Subject == current.Subject && current.Slug != "") {
+		pb.Slug = current.Slug
+		return nil
+	}
+
+	if pb.Slug, err = uniqueSlug(p, pb.Subject, pb.ID); err != nil {
+		return err
+	}
+	if current.Slug == "" || current.Slug == pb.Slug {
+		return nil
+	}
+
+	return db.DB.Create(&PostSlug{Slug: current.Slug, Post: pb.ID, Pattern: p}).Error
+}
+
+// ResolveSlug finds the released Post addressed by slug s and sets its ID.
+// It returns the Post's current slug, which differs from s when s is an old one.
+// Unreleased Posts are not found, so their new slugs are never given away.
+func ResolveSlug(post Post, s string) (string, error) {
+	p := patternOf(post)
+	current := PostBase{}
+	err := db.DB.Model(p.table()).Select("id, slug").Where("slug = ? AND release = ?", s, true).Row().Scan(&current.ID, &current.Slug)
+	if err == nil {
+		post.Meta().ID = current.ID
+		return current.Slug, nil
+	} else if err != sql.ErrNoRows {
+		return "", errs.Internal(err)
+	}
+
+	old := PostSlug{}
+	if err := db.DB.Where(&PostSlug{Slug: s, Pattern: p}).Order("created_at desc").First(&old).Error; err != nil {
+		return "", dbError(err)
+	}
+
+	err = db.DB.Model(p.table()).Select("slug").Where("id = ? AND release = ?", old.Post, true).Row().Scan(&current.Slug)
+	if err == sql.ErrNoRows {
+		return "", errs.NotFound(err)
+	} else if err != nil {
+		return "", errs.Internal(err)
+	}
+
+	post.Meta().ID = old.Post
+	return current.Slug, nil
+}
+
+// backfillSlugs gives a slug to every Post created before slugs existed
+func backfillSlugs() error {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		rows, err := db.DB.Model(p.table()).Select("id, subject").Where("slug = '' OR slug IS NULL").Rows()
+		if err != nil {
+			return err
+		}
+
+		pending := []PostBase{}
+		for rows.Next() {
+			pb := PostBase{}
+			if err := rows.Scan(&pb.ID, &pb.Subject); err != nil {
+				rows.Close()
+				return err
+			}
+			pending = append(pending, pb)
+		}
+		rows.Close()
+
+		for _, pb := range pending {
+			s, err := uniqueSlug(p, pb.Subject, pb.ID)
+			if err != nil {
+				return err
+			}
+			if err := db.DB.Model(p.table()).Where("id = ?", pb.ID).UpdateColumn("slug", s).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexSlugs makes slugs unique per Post type, so Posts created at the same
+// time cannot share one. It runs after backfillSlugs has filled in the blanks.
+func indexSlugs() error {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		table := db.DB.NewScope(p.table()).TableName()
+		if err := db.DB.Model(p.table()).AddUniqueIndex("uix_"+table+"_slug", "slug").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
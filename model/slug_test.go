@@ -0,0 +1,87 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestResolveSlug(t *testing.T) {
+	defer useTestDB(t, &Article{}, &PostSlug{})()
+	released := Article{PostBase: PostBase{Subject: "Public", Slug: "public-renamed", Release: true}}
+	draft := Article{PostBase: PostBase{Subject: "Secret plans", Slug: "secret-plans"}}
+	for _, a := range []*Article{&released, &draft} {
+		if err := db.DB.Create(a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for old, post := range map[string]uuid.UUID{"public": released.ID, "retracted": draft.ID} {
+		if err := db.DB.Create(&PostSlug{Slug: old, Post: post, Pattern: articlePost}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		slug       string
+		want       string
+		wantStatus int
+	}{
+		{name: "Current Slug Test", slug: "public-renamed", want: "public-renamed"},
+		{name: "Old Slug Test", slug: "public", want: "public-renamed"},
+		{name: "Draft Slug Test", slug: "secret-plans", wantStatus: http.StatusNotFound},
+		{name: "Old Draft Slug Test", slug: "retracted", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveSlug(&Article{}, tt.slug)
+			if tt.wantStatus != 0 {
+				if errs.From(err).Status != tt.wantStatus || got != "" {
+					t.Errorf("ResolveSlug() = %q, %v, want status %v", got, err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveSlug() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	defer useTestDB(t, &Article{}, &Gallery{}, &Flicker{}, &PostSlug{})()
+	if err := indexSlugs(); err != nil {
+		t.Fatalf("indexSlugs() error = %v", err)
+	}
+
+	taken := Article{PostBase: PostBase{Subject: "Hello", Slug: "hello"}}
+	if err := db.DB.Create(&taken).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DB.Create(&PostSlug{Slug: "hello-2", Post: uuid.NewV4(), Pattern: articlePost}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := uniqueSlug(articlePost, "Hello", uuid.Nil); err != nil || got != "hello-3" {
+		t.Errorf("uniqueSlug() = %q, %v, want %q", got, err, "hello-3")
+	}
+	if got, err := uniqueSlug(articlePost, "Hello", taken.ID); err != nil || got != "hello" {
+		t.Errorf("uniqueSlug() of its owner = %q, %v, want %q", got, err, "hello")
+	}
+	if got, err := uniqueSlug(galleryPost, "Hello", uuid.Nil); err != nil || got != "hello" {
+		t.Errorf("uniqueSlug() of another type = %q, %v, want %q", got, err, "hello")
+	}
+
+	clash := Article{PostBase: PostBase{Subject: "Hello", Slug: "hello"}}
+	if err := db.DB.Create(&clash).Error; err == nil {
+		t.Error("Create() with a taken slug error = nil, want unique violation")
+	}
+
+	db.DB.DropTable(&PostSlug{})
+	if _, err := uniqueSlug(articlePost, "Hello", uuid.Nil); err == nil {
+		t.Error("uniqueSlug() without a history table error = nil, want error")
+	}
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// TokenPrefix starts every personal access token, so they are easy to tell
+// apart from JWTs and to spot when leaked
+const TokenPrefix = "yap_"
+
+// tokenTouch is how stale LastUsed may get before a use updates it
+const tokenTouch = time.Minute
+
+// Scope is something a personal access token may do
+type Scope string
+
+// Scopes of personal access tokens
+const (
+	ScopeAccountRead    Scope = "account:read"
+	ScopeAccountWrite   Scope = "account:write"
+	ScopePostsRead      Scope = "posts:read"
+	ScopePostsWrite     Scope = "posts:write"
+	ScopeReactionsWrite Scope = "reactions:write"
+	ScopeListsRead      Scope = "lists:read"
+	ScopeListsWrite     Scope = "lists:write"
+	ScopeHooks          Scope = "hooks"
+	ScopeAdmin          Scope = "admin"
+)
+
+// AccessToken is a personal access token a User made for scripts.
+// Only a hash of the token is kept; Prefix helps its owner recognise it.
+type AccessToken struct {
+	Base
+	User     uuid.UUID      `json:"user" gorm:"type:uuid;index"`
+	Name     string         `json:"name"`
+	Prefix   string         `json:"prefix"`
+	Hash     string         `json:"-" gorm:"unique_index"`
+	Scopes   pq.StringArray `json:"scopes" gorm:"type:varchar(32)[]"`
+	MFA      bool           `json:"-"`
+	Expires  time.Time      `json:"expires_at"`
+	LastUsed *time.Time     `json:"last_used_at"`
+	Token    string         `json:"token,omitempty" sql:"-"`
+}
+
+// HashToken is how a personal access token is stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Has reports whether t grants scope
+func (t *AccessToken) Has(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if Scope(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Create makes an AccessToken, setting Token to the only copy of its secret
+func (t *AccessToken) Create() error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return errs.Internal(err)
+	}
+
+	t.Token = TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	t.Prefix, t.Hash = t.Token[:len(TokenPrefix)+6], HashToken(t.Token)
+	if err := db.DB.Create(t).Error; err != nil {
+		return errs.Internal(err)
+	}
+	return nil
+}
+
+// ReadAccessTokens lists the unexpired AccessTokens of a User
+func (u *User) ReadAccessTokens() ([]AccessToken, error) {
+	tokens := []AccessToken{}
+	err := db.DB.Where(&AccessToken{User: u.ID}).Where("expires > ?", time.Now()).Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return tokens, errs.Internal(err)
+	}
+	return tokens, nil
+}
+
+// Delete revokes an AccessToken of its User
+func (t *AccessToken) Delete() error {
+	return deleteError(db.DB.Where(`"user" = ?`, t.User).Delete(t))
+}
+
+// UseAccessToken returns the AccessToken with the secret token and the
+// User it acts for, recording when it was last used
+func UseAccessToken(token string) (AccessToken, User, error) {
+	t, user := AccessToken{}, User{}
+	err := db.DB.Where(&AccessToken{Hash: HashToken(token)}).Where("expires > ?", time.Now()).First(&t).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return t, user, errs.New(http.StatusUnauthorized, errs.CodeUnauthorized, "The token is invalid, revoked or expired.")
+	} else if err != nil {
+		return t, user, errs.Internal(err)
+	}
+
+	user.ID = t.User
+	if err := db.DB.First(&user).Error; err != nil {
+		return t, user, errs.Wrap(err, http.StatusUnauthorized, errs.CodeUnauthorized, "The token's user no longer exists.")
+	}
+
+	now := time.Now().UTC()
+	if t.LastUsed == nil || now.Sub(*t.LastUsed) > tokenTouch {
+		if err := db.DB.Model(&t).UpdateColumn("last_used", now).Error; err != nil {
+			return t, user, errs.Internal(err)
+		}
+	}
+	return t, user, nil
+}
@@ -0,0 +1,272 @@
+package model
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditActions of the trash
+const (
+	AuditPostRestore     AuditAction = "post.restore"
+	AuditReactionRestore AuditAction = "reaction.restore"
+	AuditUserRestore     AuditAction = "user.restore"
+	AuditTrashPurge      AuditAction = "trash.purge"
+)
+
+// PurgeCounts is how many of each thing a purge removed for good
+type PurgeCounts struct {
+	Posts     int `json:"posts"`
+	Reactions int `json:"reactions"`
+	Users     int `json:"users"`
+}
+
+// notTrashed is returned when restoring something that is not in the trash
+var notTrashed = errs.New(http.StatusNotFound, errs.CodeNotFound, "This is not in the trash.")
+
+// trashed scopes a query to soft-deleted rows
+func trashed() *gorm.DB {
+	return db.DB.Unscoped().Where("deleted_at IS NOT NULL")
+}
+
+// ReadTrashedPosts fetches deleted Posts of every pattern, most recently deleted first.
+// A nil creator reads everyone's.
+func ReadTrashedPosts(creator uuid.UUID) ([]Post, error) {
+	query := trashed()
+	if !uuid.Equal(creator, uuid.Nil) {
+		query = query.Where("creator = ?", creator)
+	}
+
+	posts, err := findPosts(query)
+	if err != nil {
+		return posts, err
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Meta().DeletedAt.After(*posts[j].Meta().DeletedAt)
+	})
+	return posts, nil
+}
+
+// ReadTrashedReactions fetches deleted Reactions, most recently deleted first.
+// A nil user reads everyone's.
+func ReadTrashedReactions(user uuid.UUID) ([]Reaction, error) {
+	query := trashed()
+	if !uuid.Equal(user, uuid.Nil) {
+		query = query.Where(`"user" = ?`, user)
+	}
+
+	reactions := []Reaction{}
+	if err := query.Order("deleted_at desc").Find(&reactions).Error; err != nil {
+		return reactions, dbError(err)
+	}
+
+	return reactions, nil
+}
+
+// ReadTrashedUsers fetches deleted Users, most recently deleted first
+func ReadTrashedUsers() ([]User, error) {
+	users := []User{}
+	if err := trashed().Order("deleted_at desc").Find(&users).Error; err != nil {
+		return users, dbError(err)
+	}
+
+	return users, nil
+}
+
+// PeekTrashedPost finds a deleted Post across Post models
+func PeekTrashedPost(id uuid.UUID) (Post, error) {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		post := p.table().(Post)
+		if err := trashed().Where("id = ?", id).First(post).Error; err == nil {
+			return post, nil
+		} else if !gorm.IsRecordNotFoundError(err) {
+			return nil, errs.Internal(err)
+		}
+	}
+
+	return nil, notTrashed
+}
+
+// RestorePost takes a deleted Post out of the trash on behalf of by
+func RestorePost(p Post, by Actor) error {
+	pb := p.Meta()
+	return restore(p, by, AuditPostRestore, string(pb.Pattern), pb.ID, pb.DeletedAt)
+}
+
+// ReadTrashed fetches a deleted Reaction
+func (r *Reaction) ReadTrashed() error {
+	if err := trashed().Where("id = ?", r.ID).First(r).Error; gorm.IsRecordNotFoundError(err) {
+		return notTrashed
+	} else if err != nil {
+		return errs.Internal(err)
+	}
+	return nil
+}
+
+// Restore takes a deleted Reaction out of the trash on behalf of by
+func (r *Reaction) Restore(by Actor) error {
+	return restore(r, by, AuditReactionRestore, "reaction", r.ID, r.DeletedAt)
+}
+
+// ReadTrashed fetches a deleted User
+func (u *User) ReadTrashed() error {
+	if err := trashed().Where("id = ?", u.ID).First(u).Error; gorm.IsRecordNotFoundError(err) {
+		return notTrashed
+	} else if err != nil {
+		return errs.Internal(err)
+	}
+	return nil
+}
+
+// Restore takes a deleted User out of the trash on behalf of by,
+// along with the Posts and Reactions deleted with them.
+func (u *User) Restore(by Actor) error {
+	deleted := u.DeletedAt
+	return restore(u, by, AuditUserRestore, "user", u.ID, deleted, func(tx *gorm.DB) error {
+		for p, column := range userOwned() {
+			err := tx.Unscoped().Model(p).Where(column+" = ? AND deleted_at = ?", u.ID, deleted).UpdateColumn("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restore clears the deletion of v, runs also in the same transaction,
+// and records it in the audit log
+func restore(v interface{}, by Actor, action AuditAction, kind string, id uuid.UUID, deleted *time.Time, also ...func(*gorm.DB) error) error {
+	tx := db.DB.Begin()
+	res := tx.Unscoped().Model(v).Where("deleted_at IS NOT NULL").UpdateColumn("deleted_at", nil)
+	if res.Error != nil {
+		tx.Rollback()
+		return errs.Internal(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return notTrashed
+	}
+
+	for _, f := range also {
+		if err := f(tx); err != nil {
+			tx.Rollback()
+			return errs.Internal(err)
+		}
+	}
+
+	if err := audit(tx, by, action, kind, id, map[string]*time.Time{"deleted_at": deleted}, map[string]*time.Time{"deleted_at": nil}); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+
+	return dbError(tx.Commit().Error)
+}
+
+// userOwned maps the models a User owns to the column naming their owner
+func userOwned() map[interface{}]string {
+	return map[interface{}]string{&Article{}: "creator", &Gallery{}: "creator", &Flicker{}: "creator", &Reaction{}: `"user"`}
+}
+
+// PurgeTrash removes for good every Post, Reaction and User deleted before cutoff,
+// with what hangs off them, and records the purge in the audit log
+func PurgeTrash(cutoff time.Time) (PurgeCounts, error) {
+	counts := PurgeCounts{}
+	tx := db.DB.Begin()
+	fail := func(err error) (PurgeCounts, error) {
+		tx.Rollback()
+		return PurgeCounts{}, errs.Internal(err)
+	}
+
+	posts := []uuid.UUID{}
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		ids := []uuid.UUID{}
+		if err := tx.Unscoped().Model(p.table()).Where("deleted_at < ?", cutoff).Pluck("id", &ids).Error; err != nil {
+			return fail(err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		if err := tx.Unscoped().Where("id IN (?)", ids).Delete(p.table()).Error; err != nil {
+			return fail(err)
+		}
+		posts = append(posts, ids...)
+	}
+	counts.Posts = len(posts)
+
+	if len(posts) > 0 {
+		for v, column := range map[interface{}]string{&Reaction{}: "item", &PostSlug{}: "post", &SeriesEntry{}: "post", &ReadingItem{}: "post", &PostAuthor{}: "post", &PostTransition{}: "post", &Notification{}: "post"} {
+			if err := tx.Unscoped().Where(column+" IN (?)", posts).Delete(v).Error; err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	res := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&Reaction{})
+	if res.Error != nil {
+		return fail(res.Error)
+	}
+	counts.Reactions = int(res.RowsAffected)
+
+	users := []uuid.UUID{}
+	if err := tx.Unscoped().Model(&User{}).Where("deleted_at < ?", cutoff).Pluck("id", &users).Error; err != nil {
+		return fail(err)
+	}
+	if len(users) > 0 {
+		if err := purgeUsers(tx, users); err != nil {
+			return fail(err)
+		}
+	}
+	counts.Users = len(users)
+
+	if counts != (PurgeCounts{}) {
+		by := Actor{Agent: "trash purge"}
+		if err := audit(tx, by, AuditTrashPurge, "trash", uuid.Nil, map[string]time.Time{"cutoff": cutoff}, counts); err != nil {
+			return fail(err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return PurgeCounts{}, errs.Internal(err)
+	}
+	return counts, nil
+}
+
+// purgeUsers removes users for good in tx, with their personal data
+func purgeUsers(tx *gorm.DB, users []uuid.UUID) error {
+	if err := eraseUserData(tx, users); err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN (?)", users).Delete(&User{}).Error
+}
+
+// eraseUserData removes the lists, follows, notifications, invitations and
+// webhooks of users in tx, and every follow of them
+func eraseUserData(tx *gorm.DB, users []uuid.UUID) error {
+	lists := tx.Unscoped().Model(&ReadingList{}).Select("id").Where(`"user" IN (?)`, users).QueryExpr()
+	if err := tx.Unscoped().Where("list IN (?)", lists).Delete(&ReadingItem{}).Error; err != nil {
+		return err
+	}
+	hooks := tx.Unscoped().Model(&Webhook{}).Select("id").Where("creator IN (?)", users).QueryExpr()
+	if err := tx.Unscoped().Where("hook IN (?)", hooks).Delete(&WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+
+	for v, column := range map[interface{}]string{&ReadingList{}: `"user"`, &Follow{}: `"user"`, &Notification{}: `"user"`, &NotificationPreference{}: `"user"`, &PostAuthor{}: `"user"`, &RecoveryCode{}: `"user"`, &Identity{}: `"user"`, &AccessToken{}: `"user"`, &Session{}: `"user"`, &Webhook{}: "creator"} {
+		if err := tx.Unscoped().Where(column+" IN (?)", users).Delete(v).Error; err != nil {
+			return err
+		}
+	}
+
+	targets := []string{}
+	for _, id := range users {
+		targets = append(targets, id.String())
+	}
+	return tx.Unscoped().Where("kind = ? AND target IN (?)", FollowUser, targets).Delete(&Follow{}).Error
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditActions of two-factor authentication
+const (
+	AuditTwoFactorDisable AuditAction = "user.two_factor_disable"
+	AuditTwoFactorPolicy  AuditAction = "policy.two_factor"
+)
+
+// RecoveryCode is a hashed one-time code that stands in for a TOTP code
+type RecoveryCode struct {
+	Base
+	User uuid.UUID  `json:"user" gorm:"type:uuid;index"`
+	Hash string     `json:"-" gorm:"index"`
+	Used *time.Time `json:"used_at"`
+}
+
+// Setting is a site-wide setting, stored as JSON
+type Setting struct {
+	Name      string `gorm:"primary_key"`
+	Value     string
+	UpdatedAt time.Time
+}
+
+// settingTwoFactorRoles names the Setting listing the roles that must use two-factor authentication
+const settingTwoFactorRoles = "two_factor_roles"
+
+// twoFactorRoles caches the roles that must use two-factor authentication
+var twoFactorRoles struct {
+	sync.RWMutex
+	roles []UserRole
+}
+
+// EnrollTOTP stores secret as the User's pending TOTP secret.
+// It takes effect once EnableTOTP confirms a code from it.
+func (u *User) EnrollTOTP(secret string) error {
+	if u.TwoFactor {
+		return errs.New(http.StatusConflict, errs.CodeConflict, "Two-factor authentication is already enabled.")
+	}
+
+	u.TOTP = secret
+	return dbError(db.DB.Model(u).UpdateColumn("totp", secret).Error)
+}
+
+// EnableTOTP turns on two-factor authentication for a User whose code at
+// step checked out, and replaces their recovery codes with hashes
+func (u *User) EnableTOTP(step int64, hashes []string) error {
+	tx := db.DB.Begin()
+	err := tx.Model(u).UpdateColumns(map[string]interface{}{"two_factor": true, "totp_step": step}).Error
+	if err == nil {
+		err = replaceRecoveryCodes(tx, u.ID, hashes)
+	}
+	if err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+	u.TwoFactor, u.TOTPStep = true, step
+	return nil
+}
+
+// DisableTOTP turns off two-factor authentication for a User on behalf of by
+func (u *User) DisableTOTP(by Actor) error {
+	tx := db.DB.Begin()
+	err := tx.Model(u).UpdateColumns(map[string]interface{}{"two_factor": false, "totp": "", "totp_step": 0}).Error
+	if err == nil {
+		err = replaceRecoveryCodes(tx, u.ID, nil)
+	}
+	if err == nil {
+		err = audit(tx, by, AuditTwoFactorDisable, "user", u.ID, map[string]bool{"two_factor": true}, map[string]bool{"two_factor": false})
+	}
+	if err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+	u.TwoFactor, u.TOTP, u.TOTPStep = false, "", 0
+	return nil
+}
+
+// UseTOTPStep records that the User's TOTP code at step was used.
+// It reports false if that step or a later one was used already,
+// so a code cannot be replayed.
+func (u *User) UseTOTPStep(step int64) (bool, error) {
+	res := db.DB.Model(&User{}).Where("id = ? AND totp_step < ?", u.ID, step).UpdateColumn("totp_step", step)
+	if res.Error != nil {
+		return false, errs.Internal(res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes gives a User new recovery codes with hashes,
+// and voids their old ones
+func (u *User) ReplaceRecoveryCodes(hashes []string) error {
+	tx := db.DB.Begin()
+	if err := replaceRecoveryCodes(tx, u.ID, hashes); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+	return dbError(tx.Commit().Error)
+}
+
+// UseRecoveryCode spends the User's unused recovery code with hash.
+// It reports false if there is no such code.
+func (u *User) UseRecoveryCode(hash string) (bool, error) {
+	res := db.DB.Model(&RecoveryCode{}).Where(`"user" = ? AND hash = ? AND used IS NULL`, u.ID, hash).UpdateColumn("used", time.Now().UTC())
+	if res.Error != nil {
+		return false, errs.Internal(res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// CountRecoveryCodes counts the User's unused recovery codes
+func (u *User) CountRecoveryCodes() (int, error) {
+	var count int
+	if err := db.DB.Model(&RecoveryCode{}).Where(`"user" = ? AND used IS NULL`, u.ID).Count(&count).Error; err != nil {
+		return 0, errs.Internal(err)
+	}
+	return count, nil
+}
+
+// replaceRecoveryCodes removes the recovery codes of user in tx and stores hashes in their place
+func replaceRecoveryCodes(tx *gorm.DB, user uuid.UUID, hashes []string) error {
+	if err := tx.Unscoped().Where(`"user" = ?`, user).Delete(&RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if err := tx.Create(&RecoveryCode{User: user, Hash: h}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TwoFactorRoles lists the roles that must use two-factor authentication
+func TwoFactorRoles() []UserRole {
+	twoFactorRoles.RLock()
+	defer twoFactorRoles.RUnlock()
+	return append([]UserRole{}, twoFactorRoles.roles...)
+}
+
+// TwoFactorRequired reports whether Users with role must use two-factor authentication
+func TwoFactorRequired(role UserRole) bool {
+	for _, r := range TwoFactorRoles() {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTwoFactorRoles makes Users with roles use two-factor authentication, on behalf of by
+func SetTwoFactorRoles(roles []UserRole, by Actor) error {
+	value, err := json.Marshal(roles)
+	if err != nil {
+		return errs.Internal(err)
+	}
+
+	before := TwoFactorRoles()
+	tx := db.DB.Begin()
+	err = tx.Where(Setting{Name: settingTwoFactorRoles}).Assign(Setting{Value: string(value)}).FirstOrCreate(&Setting{}).Error
+	if err == nil {
+		err = audit(tx, by, AuditTwoFactorPolicy, "policy", uuid.Nil, map[string][]UserRole{"roles": before}, map[string][]UserRole{"roles": roles})
+	}
+	if err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	twoFactorRoles.Lock()
+	twoFactorRoles.roles = append([]UserRole{}, roles...)
+	twoFactorRoles.Unlock()
+	return nil
+}
+
+// loadTwoFactorRoles reads the roles that must use two-factor authentication into the cache
+func loadTwoFactorRoles() error {
+	s := Setting{}
+	err := db.DB.Where(Setting{Name: settingTwoFactorRoles}).First(&s).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	roles := []UserRole{}
+	if err := json.Unmarshal([]byte(s.Value), &roles); err != nil {
+		return err
+	}
+
+	twoFactorRoles.Lock()
+	twoFactorRoles.roles = roles
+	twoFactorRoles.Unlock()
+	return nil
+}
@@ -1,27 +1,42 @@
 package model
 
 import (
-	"errors"
 	"net/http"
+	"time"
 
 	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
 
 	"github.com/jinzhu/gorm"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // User is a registered user
+// MailVerified is set when a login provider vouched for Mail, and cleared
+// when Mail changes.
 // TODO User status
 type User struct {
 	Base
-	Name      string     `json:"name"`
-	Mail      string     `json:"mail"`
-	Pass      string     `json:"pass"`
-	Auth      string     `json:"auth"`
-	Life      string     `json:"life"`
-	Role      UserRole   `json:"role"`
-	Posts     []Post     `json:"posts,omitempty" sql:"-" gorm:"foreignkey:Creator"`
-	Reactions []Reaction `json:"reactions,omitempty" sql:"-" gorm:"foreignkey:User"`
+	Handle       string         `json:"handle" gorm:"unique_index"`
+	Name         string         `json:"name"`
+	Mail         string         `json:"mail"`
+	MailVerified bool           `json:"mail_verified"`
+	Pass         string         `json:"pass"`
+	Auth         string         `json:"auth"`
+	Life         string         `json:"life"`
+	Avatar       string         `json:"avatar"`
+	Links        pq.StringArray `json:"links" gorm:"type:varchar(2048)[]"`
+	Location     string         `json:"location"`
+	Hidden       pq.StringArray `json:"hidden" gorm:"type:varchar(16)[]"`
+	Role         UserRole       `json:"role"`
+	TwoFactor    bool           `json:"two_factor"`
+	TOTP         string         `json:"-" gorm:"column:totp"`
+	TOTPStep     int64          `json:"-" gorm:"column:totp_step"`
+	Posts        []Post         `json:"posts,omitempty" sql:"-" gorm:"foreignkey:Creator"`
+	Reactions    []Reaction     `json:"reactions,omitempty" sql:"-" gorm:"foreignkey:User"`
+	Follows      *FollowCounts  `json:"follows,omitempty" sql:"-"`
 }
 
 // UserRole represents a user rank
@@ -36,18 +51,29 @@ const (
 
 // Create makes a User
 // First user is automatically promoted to "UserKeeper" role
-func (u *User) Create() (int, error) {
-	if num, status, err := CountUsers(&User{Mail: u.Mail}); num > 0 {
-		status := http.StatusBadRequest
-		return status, errors.New(http.StatusText(status))
-	} else if err != nil {
-		return status, err
+// Users who pick no handle get one made from their name
+func (u *User) Create() error {
+	if num, err := CountUsers(&User{Mail: u.Mail}); err != nil {
+		return err
+	} else if num > 0 {
+		return errs.New(http.StatusConflict, errs.CodeMailTaken, "This mail is already registered.").
+			WithField("mail", string(errs.CodeMailTaken), "")
+	}
+
+	if u.Handle != "" {
+		if err := u.checkHandle(); err != nil {
+			return err
+		}
+	} else if handle, err := uniqueHandle(u.Name, u.ID); err != nil {
+		return errs.Internal(err)
+	} else {
+		u.Handle = handle
 	}
 
 	var count int
 	hash, err := bcrypt.GenerateFromPassword([]byte(u.Pass), bcrypt.DefaultCost)
 	if err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	u.Pass = string(hash)
@@ -57,138 +83,157 @@ func (u *User) Create() (int, error) {
 	}
 
 	if err = db.DB.Create(u).Error; err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
-	return http.StatusCreated, nil
+	return nil
 }
 
 // Read fetches a User
-func (u *User) Read() (int, error) {
-	if err := db.DB.Set("gorm:auto_preload", true).First(u).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
-	}
-
-	return http.StatusOK, nil
+func (u *User) Read() error {
+	return dbError(db.DB.Set("gorm:auto_preload", true).First(u).Error)
 }
 
-// Update edits a User
-func (u *User) Update() (int, error) {
+// Update edits a User.
+// Handle, Name, Mail and Pass are left alone when empty; the profile fields
+// are always written, so they can be emptied.
+func (u *User) Update() error {
+	if u.Handle != "" {
+		if err := u.checkHandle(); err != nil {
+			return err
+		}
+	}
+
 	if u.Pass != "" {
 		hash, err := bcrypt.GenerateFromPassword([]byte(u.Pass), bcrypt.DefaultCost)
 		if err != nil {
-			return http.StatusInternalServerError, err
+			return errs.Internal(err)
 		}
 
 		u.Pass = string(hash)
 	}
 
 	user := User{
-		Name: u.Name,
-		Mail: u.Mail,
-		Pass: u.Pass,
-		Role: u.Role,
-		Life: u.Life,
+		Handle: u.Handle,
+		Name:   u.Name,
+		Mail:   u.Mail,
+		Pass:   u.Pass,
+		Role:   u.Role,
 	}
 
-	if err := db.DB.Model(u).Updates(user).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	if u.Mail != "" {
+		if err := db.DB.Model(u).Where("mail <> ?", u.Mail).UpdateColumn("mail_verified", false).Error; err != nil {
+			return dbError(err)
+		}
 	}
 
-	if err := db.DB.First(u).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	if err := db.DB.Model(u).Updates(user).Error; err != nil {
+		return dbError(err)
 	}
 
-	return http.StatusAccepted, nil
+	profile := map[string]interface{}{"life": u.Life, "avatar": u.Avatar, "links": u.Links, "location": u.Location, "hidden": u.Hidden}
+	if err := db.DB.Model(u).Updates(profile).Error; err != nil {
+		return dbError(err)
+	}
+
+	return dbError(db.DB.First(u).Error)
 }
 
-// Delete removes a User
-func (u *User) Delete() (int, error) {
-	postBase := PostBase{
-		Creator: u.ID,
-	}
+// Assignment is the event published when a User is given a new Role.
+// It leaves out the rest of the User, since webhooks may be sent it.
+type Assignment struct {
+	User uuid.UUID `json:"user"`
+	Role UserRole  `json:"role"`
+}
 
-	if err := db.DB.Delete(&Article{PostBase: postBase}).Error; err != nil {
-		return http.StatusInternalServerError, err
+// Assign changes the Role of a User on behalf of by
+func (u *User) Assign(role UserRole, by Actor) error {
+	before := u.Role
+	tx := db.DB.Begin()
+	if err := tx.Model(u).Update("role", role).Error; err != nil {
+		tx.Rollback()
+		return dbError(err)
 	}
 
-	if err := db.DB.Delete(&Gallery{PostBase: postBase}).Error; err != nil {
-		return http.StatusInternalServerError, err
+	after := map[string]UserRole{"role": role}
+	if err := audit(tx, by, AuditUserAssign, "user", u.ID, map[string]UserRole{"role": before}, after); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
 	}
 
-	if err := db.DB.Delete(&Flicker{PostBase: postBase}).Error; err != nil {
-		return http.StatusInternalServerError, err
+	if err := tx.Commit().Error; err != nil {
+		return errs.Internal(err)
 	}
+	return dbError(db.DB.First(u).Error)
+}
 
-	if err := db.DB.Delete(&Reaction{User: u.ID}).Error; err != nil {
-		return http.StatusInternalServerError, err
+// Delete moves a User to the trash along with their Posts and Reactions on
+// behalf of by. They share one deletion time, so Restore brings them back together.
+func (u *User) Delete(by Actor) error {
+	now := gorm.NowFunc().Truncate(time.Microsecond)
+	tx := db.DB.Begin()
+	for p, column := range userOwned() {
+		if err := tx.Model(p).Where(column+" = ?", u.ID).UpdateColumn("deleted_at", now).Error; err != nil {
+			tx.Rollback()
+			return errs.Internal(err)
+		}
 	}
 
-	db := db.DB.Delete(u)
-	if num, err := db.RowsAffected, db.Error; num == 0 {
-		return http.StatusNotFound, gorm.ErrRecordNotFound
-	} else if err != nil {
-		return http.StatusInternalServerError, err
+	res := tx.Model(u).UpdateColumn("deleted_at", now)
+	if err := deleteError(res); err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	return http.StatusAccepted, nil
-}
-
-// ValidateAuth checks user details format
-func (u *User) ValidateAuth() (int, error) {
-	code := http.StatusOK
-	if u.Mail == "" || u.Pass == "" {
-		code = http.StatusBadRequest
-		return code, errors.New(http.StatusText(code))
+	before := map[string]interface{}{"name": u.Name, "mail": u.Mail, "role": u.Role}
+	if err := audit(tx, by, AuditUserDelete, "user", u.ID, before, nil); err != nil {
+		tx.Rollback()
+		return errs.Internal(err)
 	}
 
-	return code, nil
+	return dbError(tx.Commit().Error)
 }
 
 // TryAuth checks user credentials
-func (u *User) TryAuth() (int, error) {
+func (u *User) TryAuth() error {
 	pass := []byte(u.Pass)
 	user := &User{Mail: u.Mail}
+	badCredentials := errs.New(http.StatusUnauthorized, errs.CodeBadCredentials, "Mail or password is incorrect.")
 	if err := db.DB.Where(user).Find(user).Error; gorm.IsRecordNotFoundError(err) {
-		return http.StatusNotFound, err
+		badCredentials.Err = err
+		return badCredentials
 	} else if err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	err := bcrypt.CompareHashAndPassword([]byte(user.Pass), pass)
 	if err == bcrypt.ErrMismatchedHashAndPassword {
-		return http.StatusNotFound, err
+		badCredentials.Err = err
+		return badCredentials
 	} else if err != nil {
-		return http.StatusInternalServerError, err
+		return errs.Internal(err)
 	}
 
 	*u = *user
-	return http.StatusAccepted, nil
+	return nil
 }
 
 // ReadAllUsers fetches all Users
-func ReadAllUsers() ([]User, int, error) {
+func ReadAllUsers() ([]User, error) {
 	users := []User{}
-	if err := db.DB.Set("gorm:auto_preload", true).Find(&users).Error; gorm.IsRecordNotFoundError(err) {
-		return users, http.StatusNotFound, err
+	if err := db.DB.Set("gorm:auto_preload", true).Find(&users).Error; err != nil {
+		return users, dbError(err)
 	}
 
-	return users, http.StatusOK, nil
+	return users, nil
 }
 
 // CountUsers counts specified type of users
-func CountUsers(u *User) (int, int, error) {
+func CountUsers(u *User) (int, error) {
 	var count int
-	users := []User{}
-	if err := db.DB.Where(u).Find(&users).Count(&count).Error; err != nil {
-		return count, http.StatusOK, err
+	if err := db.DB.Model(&User{}).Where(u).Count(&count).Error; err != nil {
+		return count, errs.Internal(err)
 	}
 
-	return count, http.StatusOK, nil
+	return count, nil
 }
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/db"
+)
+
+func TestUser_Update(t *testing.T) {
+	defer useTestDB(t, &User{})()
+	user := User{Handle: "ada", Name: "Ada", Mail: "ada@example.com", Life: "Wrote the first program.", Avatar: "https://example.com/ada.png", Location: "London"}
+	if err := db.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	edit := user
+	edit.Name, edit.Avatar = "", ""
+	if err := edit.Update(); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if edit.Name != "Ada" {
+		t.Errorf("Update() Name = %q, want it left alone", edit.Name)
+	}
+	if edit.Avatar != "" || edit.Life != user.Life || edit.Location != user.Location {
+		t.Errorf("Update() profile = %q, %q, %q, want avatar cleared only", edit.Avatar, edit.Life, edit.Location)
+	}
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/event"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Webhook is a keeper-managed subscription to events.
+// Its Secret is only shown once, as Revealed when the Webhook is created.
+type Webhook struct {
+	Base
+	URL      string         `json:"url"`
+	Secret   string         `json:"-"`
+	Events   pq.StringArray `json:"events" gorm:"type:varchar(64)[]"`
+	Active   bool           `json:"active"`
+	Creator  uuid.UUID      `json:"creator" gorm:"type:uuid"`
+	Revealed string         `json:"secret,omitempty" sql:"-"`
+}
+
+// DeliveryState is the progress of a WebhookDelivery
+type DeliveryState string
+
+// DeliveryStates a WebhookDelivery moves through
+const (
+	DeliveryPending   DeliveryState = "pending"
+	DeliveryDelivered DeliveryState = "delivered"
+	DeliveryFailed    DeliveryState = "failed"
+)
+
+// WebhookDelivery is one Event queued for, and logged against, a Webhook
+type WebhookDelivery struct {
+	Base
+	Hook         uuid.UUID     `json:"hook" gorm:"type:uuid;index"`
+	Event        uuid.UUID     `json:"event" gorm:"type:uuid"`
+	Type         event.Type    `json:"type"`
+	Payload      string        `json:"payload" gorm:"type:text"`
+	State        DeliveryState `json:"state" gorm:"index"`
+	Attempts     int           `json:"attempts"`
+	NextAttempt  time.Time     `json:"next_attempt" gorm:"index"`
+	ResponseCode int           `json:"response_code"`
+	LastError    string        `json:"last_error"`
+	DeliveredAt  *time.Time    `json:"delivered_at"`
+}
+
+// Wants reports whether a Webhook subscribes to events of type t
+func (w *Webhook) Wants(t event.Type) bool {
+	for _, e := range w.Events {
+		if e == string(t) || (e == "*" && t.Public()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create makes a Webhook
+func (w *Webhook) Create() error {
+	return dbError(db.DB.Create(w).Error)
+}
+
+// Read fetches a Webhook
+func (w *Webhook) Read() error {
+	return dbError(db.DB.First(w).Error)
+}
+
+// Update edits a Webhook
+func (w *Webhook) Update() error {
+	update := map[string]interface{}{"url": w.URL, "events": w.Events, "active": w.Active}
+	if err := db.DB.Model(w).Updates(update).Error; err != nil {
+		return dbError(err)
+	}
+
+	return dbError(db.DB.First(w).Error)
+}
+
+// Delete removes a Webhook and its deliveries
+func (w *Webhook) Delete() error {
+	if err := db.DB.Where(&WebhookDelivery{Hook: w.ID}).Delete(&WebhookDelivery{}).Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	return deleteError(db.DB.Delete(w))
+}
+
+// Deliveries fetches the delivery log of a Webhook, newest first
+func (w *Webhook) Deliveries() ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	err := db.DB.Where(&WebhookDelivery{Hook: w.ID}).Order("created_at desc").Limit(100).Find(&deliveries).Error
+	return deliveries, dbError(err)
+}
+
+// ReadAllWebhooks fetches all Webhooks
+func ReadAllWebhooks() ([]Webhook, error) {
+	hooks := []Webhook{}
+	if err := db.DB.Find(&hooks).Error; err != nil {
+		return hooks, dbError(err)
+	}
+
+	return hooks, nil
+}
+
+// QueueDeliveries stores a pending WebhookDelivery of e for every active Webhook wanting it
+func QueueDeliveries(e event.Event) error {
+	hooks := []Webhook{}
+	if err := db.DB.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		return errs.Internal(err)
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return errs.Internal(err)
+	}
+
+	for _, hook := range hooks {
+		if !hook.Wants(e.Type) {
+			continue
+		}
+
+		delivery := WebhookDelivery{
+			Hook:        hook.ID,
+			Event:       e.ID,
+			Type:        e.Type,
+			Payload:     string(payload),
+			State:       DeliveryPending,
+			NextAttempt: e.Time,
+		}
+		if err := db.DB.Create(&delivery).Error; err != nil {
+			return errs.Internal(err)
+		}
+	}
+
+	return nil
+}
+
+// DueDeliveries fetches up to limit pending deliveries whose next attempt is due
+func DueDeliveries(limit int) ([]WebhookDelivery, error) {
+	deliveries := []WebhookDelivery{}
+	err := db.DB.Where("state = ? AND next_attempt <= ?", DeliveryPending, time.Now().UTC()).
+		Order("next_attempt").Limit(limit).Find(&deliveries).Error
+	return deliveries, dbError(err)
+}
+
+// Read fetches a WebhookDelivery
+func (d *WebhookDelivery) Read() error {
+	return dbError(db.DB.First(d).Error)
+}
+
+// Succeed records a successful attempt
+func (d *WebhookDelivery) Succeed(code int) error {
+	now := time.Now().UTC()
+	d.Attempts++
+	d.State, d.ResponseCode, d.LastError, d.DeliveredAt = DeliveryDelivered, code, "", &now
+	return dbError(db.DB.Save(d).Error)
+}
+
+// Fail records a failed attempt. A nil retry gives up on the delivery.
+func (d *WebhookDelivery) Fail(code int, reason string, retry *time.Time) error {
+	d.Attempts++
+	d.ResponseCode, d.LastError = code, reason
+	if retry == nil {
+		d.State = DeliveryFailed
+	} else {
+		d.NextAttempt = *retry
+	}
+	return dbError(db.DB.Save(d).Error)
+}
+
+// Redeliver queues a fresh copy of a WebhookDelivery
+func (d *WebhookDelivery) Redeliver() (WebhookDelivery, error) {
+	delivery := WebhookDelivery{
+		Hook:        d.Hook,
+		Event:       d.Event,
+		Type:        d.Type,
+		Payload:     d.Payload,
+		State:       DeliveryPending,
+		NextAttempt: time.Now().UTC(),
+	}
+
+	return delivery, dbError(db.DB.Create(&delivery).Error)
+}
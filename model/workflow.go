@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/l3njo/yap/db"
+	"github.com/l3njo/yap/errs"
+	"github.com/l3njo/yap/workflow"
+	uuid "github.com/satori/go.uuid"
+)
+
+// PostTransition records a Post moving through the editorial workflow
+type PostTransition struct {
+	Base
+	Post    uuid.UUID       `json:"post" gorm:"type:uuid;index"`
+	Action  workflow.Action `json:"action"`
+	Prior   workflow.State  `json:"from"`
+	State   workflow.State  `json:"to"`
+	Actor   uuid.UUID       `json:"actor" gorm:"type:uuid"`
+	Comment string          `json:"comment"`
+}
+
+// TransitionPost takes action a on p on behalf of by, and records it in the
+// Post's history and the audit log.
+// Only published Posts are released; publishing stamps the release time and,
+// like retracting, starts the read count over.
+func TransitionPost(p Post, a workflow.Action, by Actor, comment string) (PostTransition, error) {
+	pb := p.Meta()
+	t := PostTransition{Post: pb.ID, Action: a, Prior: pb.State, Actor: by.User, Comment: comment}
+
+	to, ok := workflow.Next(pb.State, a)
+	if !ok {
+		detail := fmt.Sprintf("A post that is %s cannot take the %s action.", pb.State, a)
+		return t, errs.New(http.StatusConflict, errs.CodeBadTransition, detail)
+	}
+	t.State = to
+
+	values := map[string]interface{}{"state": to, "release": to == workflow.Published}
+	switch a {
+	case workflow.Publish:
+		values["released_at"], values["summons"] = time.Now().UTC(), 0
+	case workflow.Retract:
+		values["summons"] = 0
+	}
+
+	tx := db.DB.Begin()
+	res := tx.Model(p).Where("state = ?", pb.State).Updates(values)
+	if res.Error != nil {
+		tx.Rollback()
+		return t, errs.Internal(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		return t, errs.New(http.StatusConflict, errs.CodeStaleVersion, "This post changed state since it was read. Reload it and try again.")
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		tx.Rollback()
+		return t, errs.Internal(err)
+	}
+	before := map[string]interface{}{"state": t.Prior, "release": pb.Release}
+	after := map[string]interface{}{"state": to, "release": to == workflow.Published}
+	if err := audit(tx, by, AuditAction("post."+string(a)), string(pb.Pattern), pb.ID, before, after); err != nil {
+		tx.Rollback()
+		return t, errs.Internal(err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return t, errs.Internal(err)
+	}
+
+	return t, dbError(db.DB.Set("gorm:auto_preload", true).First(p).Error)
+}
+
+// ReadPostHistory fetches the workflow history of a Post, oldest first
+func ReadPostHistory(post uuid.UUID) ([]PostTransition, error) {
+	history := []PostTransition{}
+	if err := db.DB.Where(&PostTransition{Post: post}).Order("created_at").Find(&history).Error; err != nil {
+		return history, dbError(err)
+	}
+
+	return history, nil
+}
+
+// backfillStates puts posts released before the workflow existed in the published state
+func backfillStates() error {
+	for _, p := range []postPattern{articlePost, galleryPost, flickerPost} {
+		err := db.DB.Model(p.table()).Unscoped().
+			Where("release = ? AND state = ?", true, workflow.Draft).
+			UpdateColumn("state", workflow.Published).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Start turns published events into Notifications
+func Start() {
+	event.Subscribe(func(e event.Event) {
+		for _, n := range notificationsFor(e) {
+			if uuid.Equal(n.User, e.Actor) || uuid.Equal(n.User, uuid.Nil) {
+				continue
+			}
+			if err := n.Create(); err != nil {
+				log.Println("notify:", err)
+				continue
+			}
+			if !uuid.Equal(n.ID, uuid.Nil) {
+				event.Publish(event.New(event.NotificationCreated, e.Actor, n))
+			}
+		}
+	})
+}
+
+// notificationsFor lists the Notifications an Event should produce
+func notificationsFor(e event.Event) []model.Notification {
+	switch data := e.Data.(type) {
+	case model.Reaction:
+		if e.Type != event.ReactionCreated {
+			return nil
+		}
+		return reactionNotifications(e, data)
+	case model.Post:
+		return postNotifications(e, data.Meta())
+	case model.PostAuthor:
+		if e.Type != event.AuthorInvited {
+			return nil
+		}
+		return inviteNotifications(e, data)
+	case model.Assignment:
+		return []model.Notification{{
+			User:  data.User,
+			Kind:  model.NotifyRole,
+			Actor: e.Actor,
+			Text:  fmt.Sprintf("Your role is now %s.", data.Role),
+		}}
+	}
+	return nil
+}
+
+// reactionNotifications tells a Post's creator about a new Reaction
+func reactionNotifications(e event.Event, r model.Reaction) []model.Notification {
+	post, err := model.PeekPost(r.Item)
+	if err != nil {
+		return nil
+	}
+
+	meta := post.Meta()
+	n := model.Notification{User: meta.Creator, Actor: e.Actor, Post: meta.ID}
+	switch r.Type {
+	case model.ReactionComment:
+		n.Kind, n.Text = model.NotifyComment, fmt.Sprintf("New comment on %q.", meta.Subject)
+	case model.ReactionApprove:
+		n.Kind, n.Text = model.NotifyApproval, fmt.Sprintf("Someone approved %q.", meta.Subject)
+	default:
+		n.Kind, n.Text = model.NotifySticker, fmt.Sprintf("New sticker on %q.", meta.Subject)
+	}
+	return []model.Notification{n}
+}
+
+// postNotifications tells a Post's creator about changes made to it
+func postNotifications(e event.Event, meta *model.PostBase) []model.Notification {
+	n := model.Notification{User: meta.Creator, Actor: e.Actor, Post: meta.ID}
+	switch e.Type {
+	case event.PostPublished:
+		n.Kind, n.Text = model.NotifyPublish, fmt.Sprintf("%q was published.", meta.Subject)
+	case event.PostRetracted:
+		n.Kind, n.Text = model.NotifyRetract, fmt.Sprintf("%q was retracted.", meta.Subject)
+	case event.PostApproved:
+		n.Kind, n.Text = model.NotifyReview, fmt.Sprintf("%q was approved for publishing.", meta.Subject)
+	case event.PostChangesRequested:
+		n.Kind, n.Text = model.NotifyReview, fmt.Sprintf("Changes were requested on %q.", meta.Subject)
+	case event.PostTransferred:
+		n.Kind, n.Text = model.NotifyTransfer, fmt.Sprintf("%q was transferred to you.", meta.Subject)
+	default:
+		return nil
+	}
+	return []model.Notification{n}
+}
+
+// inviteNotifications tells a User they were invited to work on a Post
+func inviteNotifications(e event.Event, pa model.PostAuthor) []model.Notification {
+	post, err := model.PeekPost(pa.Post)
+	if err != nil {
+		return nil
+	}
+
+	return []model.Notification{{
+		User:  pa.User,
+		Kind:  model.NotifyInvite,
+		Actor: e.Actor,
+		Post:  pa.Post,
+		Text:  fmt.Sprintf("You were invited to %q as %s.", post.Meta().Subject, pa.Role),
+	}}
+}
@@ -0,0 +1,211 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// localKeyID names the only signing key of a Local provider
+const localKeyID = "local"
+
+// localCodeLife is how long a Local authorization code can be exchanged
+const localCodeLife = time.Minute
+
+// Local is a stand-in OpenID Connect provider for development and tests.
+// Anyone can log in to it as any verified email address, so it must never
+// be enabled in production.
+type Local struct {
+	Issuer   string
+	ClientID string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]localCode
+}
+
+// localCode is an authorization code waiting to be exchanged
+type localCode struct {
+	Redirect  string
+	Challenge string
+	Nonce     string
+	Email     string
+	Name      string
+	Expires   time.Time
+}
+
+// localForm asks who to log in as. It posts back to the same URL, query and all.
+var localForm = template.Must(template.New("local").Parse(`<!DOCTYPE html>
+<title>Local login</title>
+<h1>Local login</h1>
+<p>This stand-in provider logs anyone in. Do not use it in production.</p>
+<form method="post">
+<label>Email <input type="email" name="email" required></label>
+<label>Name <input name="name"></label>
+<button>Log in</button>
+</form>
+`))
+
+// NewLocal makes a Local provider served at issuer, for the client clientID
+func NewLocal(issuer, clientID string) (*Local, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{Issuer: issuer, ClientID: clientID, key: key, codes: map[string]localCode{}}, nil
+}
+
+// Provider is the Provider that logs in with l
+func (l *Local) Provider(name, base string) *Provider {
+	return &Provider{Name: name, Issuer: l.Issuer, ClientID: l.ClientID, RedirectURL: CallbackURL(base, name)}
+}
+
+// ServeHTTP serves discovery, authorization, token and key set endpoints
+// below the path of l's Issuer
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/.well-known/openid-configuration"):
+		l.serveJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                l.Issuer,
+			"authorization_endpoint":                l.Issuer + "/authorize",
+			"token_endpoint":                        l.Issuer + "/token",
+			"jwks_uri":                              l.Issuer + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+		})
+	case strings.HasSuffix(r.URL.Path, "/authorize"):
+		l.authorize(w, r)
+	case strings.HasSuffix(r.URL.Path, "/token"):
+		l.token(w, r)
+	case strings.HasSuffix(r.URL.Path, "/jwks"):
+		l.serveJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": localKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(l.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(l.key.E)).Bytes()),
+		}}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorize asks who to log in as, then redirects back with a code.
+// A login_hint skips the question.
+func (l *Local) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.Form
+	if q.Get("client_id") != l.ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	email := q.Get("email")
+	if email == "" {
+		email = q.Get("login_hint")
+	}
+	if email == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		localForm.Execute(w, nil)
+		return
+	}
+
+	code, err := Random()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	l.mu.Lock()
+	for c, lc := range l.codes {
+		if time.Now().After(lc.Expires) {
+			delete(l.codes, c)
+		}
+	}
+	l.codes[code] = localCode{
+		Redirect:  redirect.String(),
+		Challenge: q.Get("code_challenge"),
+		Nonce:     q.Get("nonce"),
+		Email:     email,
+		Name:      q.Get("name"),
+		Expires:   time.Now().Add(localCodeLife),
+	}
+	l.mu.Unlock()
+
+	v := redirect.Query()
+	v.Set("code", code)
+	v.Set("state", q.Get("state"))
+	redirect.RawQuery = v.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token exchanges a code for an ID token, checking its PKCE verifier
+func (l *Local) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		l.serveJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	l.mu.Lock()
+	lc, ok := l.codes[code]
+	delete(l.codes, code)
+	l.mu.Unlock()
+
+	if !ok || time.Now().After(lc.Expires) || r.PostForm.Get("client_id") != l.ClientID ||
+		r.PostForm.Get("redirect_uri") != lc.Redirect || Challenge(r.PostForm.Get("code_verifier")) != lc.Challenge {
+		l.serveJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            l.Issuer,
+		"sub":            "local:" + strings.ToLower(lc.Email),
+		"aud":            l.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          lc.Nonce,
+		"email":          lc.Email,
+		"email_verified": true,
+		"name":           lc.Name,
+	})
+	token.Header["kid"] = localKeyID
+
+	idToken, err := token.SignedString(l.key)
+	if err != nil {
+		l.serveJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	l.serveJSON(w, http.StatusOK, map[string]interface{}{"access_token": code, "token_type": "Bearer", "id_token": idToken, "expires_in": 300})
+}
+
+// serveJSON responds with v as JSON
+func (l *Local) serveJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
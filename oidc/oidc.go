@@ -0,0 +1,329 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Client makes every request to providers
+var Client = &http.Client{Timeout: 10 * time.Second}
+
+// DefaultScopes are requested when a Provider names none
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Provider is an OpenID Connect provider that Users can log in with.
+// Its endpoints are discovered from Issuer on first use.
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	mu   sync.Mutex
+	meta *metadata
+	keys map[string]interface{}
+}
+
+// Identity is who a provider says logged in
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// metadata is the part of a provider's discovery document yap uses
+type metadata struct {
+	Issuer string `json:"issuer"`
+	Auth   string `json:"authorization_endpoint"`
+	Token  string `json:"token_endpoint"`
+	JWKS   string `json:"jwks_uri"`
+}
+
+// jwk is a public key in a JSON Web Key Set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Configure reads the Providers named in OIDC_PROVIDERS from getenv.
+// Each name has OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET and optionally OIDC_<NAME>_SCOPES, and
+// redirects back to base + "/users/oidc/<name>/callback".
+func Configure(getenv func(string) string, base string) ([]*Provider, error) {
+	providers := []*Provider{}
+	for _, name := range strings.Split(getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := &Provider{
+			Name:         name,
+			Issuer:       getenv(prefix + "ISSUER"),
+			ClientID:     getenv(prefix + "CLIENT_ID"),
+			ClientSecret: getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  CallbackURL(base, name),
+			Scopes:       strings.Fields(getenv(prefix + "SCOPES")),
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("oidc: %sISSUER and %sCLIENT_ID must be set", prefix, prefix)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// CallbackURL is where the provider name sends Users back to after they log in
+func CallbackURL(base, name string) string {
+	return strings.TrimSuffix(base, "/") + "/users/oidc/" + name + "/callback"
+}
+
+// Random makes a random URL-safe string, for states, nonces and PKCE verifiers
+func Random() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge is the S256 PKCE challenge of verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthURL is where to send a User to log in with p
+func (p *Provider) AuthURL(state, nonce, verifier string) (string, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.ClientID)
+	v.Set("redirect_uri", p.RedirectURL)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", Challenge(verifier))
+	v.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.Auth, "?") {
+		sep = "&"
+	}
+	return meta.Auth + sep + v.Encode(), nil
+}
+
+// Exchange trades a code from p's callback for the Identity in its ID token.
+// verifier and nonce must be the ones AuthURL was given.
+func (p *Provider) Exchange(code, verifier, nonce string) (Identity, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", p.RedirectURL)
+	v.Set("client_id", p.ClientID)
+	v.Set("code_verifier", verifier)
+	if p.ClientSecret != "" {
+		v.Set("client_secret", p.ClientSecret)
+	}
+
+	res, err := Client.PostForm(meta.Token, v)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer res.Body.Close()
+
+	body := struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return Identity{}, fmt.Errorf("oidc: reading token response: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || body.IDToken == "" {
+		return Identity{}, fmt.Errorf("oidc: token endpoint said %d %s", res.StatusCode, body.Error)
+	}
+
+	return p.verify(body.IDToken, nonce)
+}
+
+// verify checks an ID token from p and returns its Identity
+func (p *Provider) verify(token, nonce string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method {
+		case jwt.SigningMethodRS256, jwt.SigningMethodES256:
+		default:
+			return nil, fmt.Errorf("oidc: unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.key(kid)
+	})
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if !claims.VerifyIssuer(p.meta.Issuer, true) {
+		return Identity{}, errors.New("oidc: ID token has the wrong issuer")
+	}
+	if !claims.VerifyAudience(p.ClientID, true) {
+		return Identity{}, errors.New("oidc: ID token is for another client")
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Identity{}, errors.New("oidc: ID token has expired")
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return Identity{}, errors.New("oidc: ID token has the wrong nonce")
+	}
+
+	id := Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string:
+		id.EmailVerified = v == "true"
+	}
+	if id.Subject == "" {
+		return Identity{}, errors.New("oidc: ID token has no subject")
+	}
+	return id, nil
+}
+
+// discover fetches p's discovery document, once it succeeds
+func (p *Provider) discover() (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	meta := &metadata{}
+	if err := getJSON(strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != p.Issuer {
+		return nil, fmt.Errorf("oidc: %s claims to be issuer %s", p.Issuer, meta.Issuer)
+	}
+	if meta.Auth == "" || meta.Token == "" || meta.JWKS == "" {
+		return nil, fmt.Errorf("oidc: %s is missing endpoints", p.Issuer)
+	}
+
+	p.meta = meta
+	return meta, nil
+}
+
+// key returns p's public key with ID kid, refetching the key set when kid is new
+func (p *Provider) key(kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := getJSON(p.meta.JWKS, &set); err != nil {
+		return nil, err
+	}
+
+	p.keys = map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if pub, err := k.public(); err == nil {
+			p.keys[k.Kid] = pub
+		}
+	}
+
+	if k, ok := p.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("oidc: no key %q", kid)
+}
+
+// public decodes k into an *rsa.PublicKey or *ecdsa.PublicKey
+func (k jwk) public() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("oidc: unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("oidc: unsupported key type %s", k.Kty)
+}
+
+// getJSON decodes the JSON at u into v
+func getJSON(u string, v interface{}) error {
+	res, err := Client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: GET %s said %d", u, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
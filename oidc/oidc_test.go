@@ -0,0 +1,100 @@
+package oidc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// login runs the authorization code flow against a Local provider as email,
+// returning the callback query
+func login(t *testing.T, p *Provider, email, state, nonce, verifier string) url.Values {
+	auth, err := p.AuthURL(state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := client.Get(auth + "&login_hint=" + url.QueryEscape(email))
+	if err != nil {
+		t.Fatalf("GET authorize error = %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("GET authorize status = %v, want %v", res.StatusCode, http.StatusFound)
+	}
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("GET authorize Location error = %v", err)
+	}
+	return loc.Query()
+}
+
+func TestLocal(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	local, err := NewLocal(srv.URL+"/oidc/local", "yap")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	mux.Handle("/oidc/local/", local)
+	p := local.Provider("local", "https://yap.example")
+
+	t.Run("Round Trip Test", func(t *testing.T) {
+		q := login(t, p, "ada@example.com", "s1", "n1", "v1")
+		if q.Get("state") != "s1" {
+			t.Errorf("callback state = %v, want s1", q.Get("state"))
+		}
+
+		id, err := p.Exchange(q.Get("code"), "v1", "n1")
+		if err != nil {
+			t.Fatalf("Exchange() error = %v", err)
+		}
+		if id.Email != "ada@example.com" || !id.EmailVerified || id.Subject == "" {
+			t.Errorf("Exchange() = %+v", id)
+		}
+
+		if _, err := p.Exchange(q.Get("code"), "v1", "n1"); err == nil {
+			t.Error("Exchange() accepted a used code")
+		}
+	})
+
+	t.Run("Wrong Verifier Test", func(t *testing.T) {
+		q := login(t, p, "ada@example.com", "s2", "n2", "v2")
+		if _, err := p.Exchange(q.Get("code"), "v1", "n2"); err == nil {
+			t.Error("Exchange() accepted the wrong PKCE verifier")
+		}
+	})
+
+	t.Run("Wrong Nonce Test", func(t *testing.T) {
+		q := login(t, p, "ada@example.com", "s3", "n3", "v3")
+		if _, err := p.Exchange(q.Get("code"), "v3", "n1"); err == nil {
+			t.Error("Exchange() accepted the wrong nonce")
+		}
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	env := map[string]string{
+		"OIDC_PROVIDERS":          "Acme, ",
+		"OIDC_ACME_ISSUER":        "https://id.acme.example",
+		"OIDC_ACME_CLIENT_ID":     "yap",
+		"OIDC_ACME_CLIENT_SECRET": "shh",
+	}
+	got, err := Configure(func(k string) string { return env[k] }, "https://yap.example/")
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "acme" || got[0].RedirectURL != "https://yap.example/users/oidc/acme/callback" {
+		t.Errorf("Configure() = %+v", got)
+	}
+
+	delete(env, "OIDC_ACME_CLIENT_ID")
+	if _, err := Configure(func(k string) string { return env[k] }, ""); err == nil {
+		t.Error("Configure() accepted a provider without a client ID")
+	}
+}
@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type base struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	hidden    string
+}
+
+type sample struct {
+	base
+	Name    string   `json:"name" validate:"required,min=3,max=64"`
+	Links   []string `json:"links" validate:"max=5,dive,required,url,max=2048"`
+	Count   int      `json:"count" validate:"min=1,max=50"`
+	Ratio   float64  `json:"ratio" validate:"max=0.5"`
+	Kind    string   `json:"kind" validate:"oneof=a b"`
+	Note    *string  `json:"note"`
+	Skipped string   `json:"-"`
+}
+
+func TestDocument_Schema(t *testing.T) {
+	d := New("Test", "1")
+	ref := d.Schema(sample{})
+	if ref.Ref != "#/components/schemas/OpenapiSample" {
+		t.Fatalf("Schema() = %+v, want a $ref to OpenapiSample", ref)
+	}
+	s := d.Components.Schemas["OpenapiSample"]
+
+	names := []string{}
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	for _, name := range []string{"id", "created_at", "name", "links", "count", "ratio", "kind", "note"} {
+		if s.Properties[name] == nil {
+			t.Errorf("Schema() properties = %v, want %s", names, name)
+		}
+	}
+	for _, name := range []string{"base", "hidden", "Skipped"} {
+		if s.Properties[name] != nil {
+			t.Errorf("Schema() properties = %v, want no %s", names, name)
+		}
+	}
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("Schema() required = %v, want [name]", s.Required)
+	}
+
+	ints := func(n int) *int { return &n }
+	floats := func(n float64) *float64 { return &n }
+	tests := []struct {
+		name string
+		got  *Schema
+		want *Schema
+	}{
+		{name: "id", got: s.Properties["id"], want: &Schema{Type: "string", Format: "uuid"}},
+		{name: "name", got: s.Properties["name"], want: &Schema{Type: "string", MinLength: ints(3), MaxLength: ints(64)}},
+		{name: "links", got: s.Properties["links"], want: &Schema{Type: "array", MaxItems: ints(5), Items: &Schema{Type: "string", Format: "uri", MaxLength: ints(2048)}}},
+		{name: "count", got: s.Properties["count"], want: &Schema{Type: "integer", Minimum: floats(1), Maximum: floats(50)}},
+		{name: "ratio", got: s.Properties["ratio"], want: &Schema{Type: "number", Maximum: floats(0.5)}},
+		{name: "kind", got: s.Properties["kind"], want: &Schema{Type: "string", Enum: []string{"a", "b"}}},
+		{name: "note", got: s.Properties["note"], want: &Schema{Type: "string", Nullable: true}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("Schema() %s = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDocument_Add(t *testing.T) {
+	d := New("Test", "1")
+	d.Add("GET", "/posts/:id/history/:version", &Operation{})
+
+	op := (*d.Paths["/posts/{id}/history/{version}"])["get"]
+	if op == nil || len(op.Parameters) != 2 || op.Parameters[0].Name != "id" || op.Parameters[1].Name != "version" {
+		t.Errorf("Add() operation = %+v, want id and version path parameters", op)
+	}
+}
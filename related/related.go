@@ -0,0 +1,173 @@
+package related
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Score weights
+const (
+	MarkerWeight  = 3.0
+	SectionWeight = 2.0
+	CreatorWeight = 1.0
+	TextWeight    = 4.0
+)
+
+// MaxResults is how many related Posts are ranked and cached per Post
+const MaxResults = 20
+
+// MaxCandidates is how many of the newest Posts of each pattern sharing a
+// marker, section or creator are ranked. Posts alike only in their words
+// are not looked for.
+const MaxCandidates = 200
+
+// stopwords are left out of text similarity
+var stopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"from": true, "your": true, "you": true, "are": true, "how": true, "what": true,
+	"why": true, "into": true, "about": true, "our": true, "its": true, "not": true,
+}
+
+// Score rates how related b is to a: MarkerWeight for each shared marker,
+// SectionWeight for a shared section, CreatorWeight for a shared creator and
+// up to TextWeight for overlapping words in their subjects and summaries.
+func Score(a, b *model.PostBase) float64 {
+	score := 0.0
+
+	markers := map[string]bool{}
+	for _, m := range a.Markers {
+		markers[m] = true
+	}
+	for _, m := range b.Markers {
+		if markers[m] {
+			score += MarkerWeight
+		}
+	}
+
+	if a.Section != "" && a.Section == b.Section {
+		score += SectionWeight
+	}
+	if uuid.Equal(a.Creator, b.Creator) {
+		score += CreatorWeight
+	}
+
+	return score + TextWeight*jaccard(words(a), words(b))
+}
+
+// words are the distinct words of the subject and summary of pb worth comparing
+func words(pb *model.PostBase) map[string]bool {
+	set := map[string]bool{}
+	split := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+	for _, w := range strings.FieldsFunc(strings.ToLower(pb.Subject+" "+pb.Summary), split) {
+		if len(w) > 2 && !stopwords[w] {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+// jaccard is the share of words in either set that are in both
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	both := 0
+	for w := range a {
+		if b[w] {
+			both++
+		}
+	}
+	return float64(both) / float64(len(a)+len(b)-both)
+}
+
+// Rank orders candidates by how related they are to post, best first,
+// leaving out post itself and candidates that share nothing with it.
+func Rank(post model.Post, candidates []model.Post, limit int) []model.Post {
+	type scored struct {
+		post  model.Post
+		score float64
+	}
+
+	target := post.Meta()
+	var ranked []scored
+	for _, c := range candidates {
+		if uuid.Equal(c.Meta().ID, target.ID) {
+			continue
+		}
+		if s := Score(target, c.Meta()); s > 0 {
+			ranked = append(ranked, scored{c, s})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	out := []model.Post{}
+	for i := 0; i < len(ranked) && i < limit; i++ {
+		out = append(out, ranked[i].post)
+	}
+	return out
+}
+
+var (
+	mu    sync.RWMutex
+	cache = map[uuid.UUID][]model.Post{}
+	start sync.Once
+)
+
+// Start empties the cache whenever a Post changes, or a User and their
+// Posts are deleted, restored or erased. It is safe to call more than once.
+func Start() {
+	start.Do(func() {
+		event.Subscribe(func(e event.Event) {
+			switch e.Type {
+			case event.UserDeleted, event.UserRestored, event.UserErased:
+				Reset()
+				return
+			}
+			if _, ok := e.Data.(model.Post); ok {
+				Reset()
+			}
+		})
+	})
+}
+
+// Reset empties the cache
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	cache = map[uuid.UUID][]model.Post{}
+}
+
+// For returns up to limit released Posts related to post, from the cache when it can.
+func For(post model.Post, limit int) ([]model.Post, error) {
+	id := post.Meta().ID
+	mu.RLock()
+	posts, ok := cache[id]
+	mu.RUnlock()
+
+	if !ok {
+		candidates, err := model.ReadRelatedCandidates(post.Meta(), MaxCandidates)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = Rank(post, candidates, MaxResults)
+		mu.Lock()
+		cache[id] = posts
+		mu.Unlock()
+	}
+
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
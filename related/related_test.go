@@ -0,0 +1,76 @@
+package related
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/event"
+	"github.com/l3njo/yap/model"
+	uuid "github.com/satori/go.uuid"
+)
+
+func article(subject, section string, creator uuid.UUID, markers ...string) *model.Article {
+	a := &model.Article{}
+	a.ID = uuid.NewV4()
+	a.Subject, a.Section, a.Creator, a.Markers = subject, section, creator, markers
+	return a
+}
+
+func TestScore(t *testing.T) {
+	ada, bob := uuid.NewV4(), uuid.NewV4()
+	base := article("Writing a Go web server", "tutorials", ada, "go", "http")
+
+	tests := []struct {
+		name  string
+		other *model.Article
+		want  float64
+	}{
+		{name: "Unrelated Test", other: article("Baking bread", "food", bob, "baking"), want: 0},
+		{name: "Marker Test", other: article("Baking bread", "food", bob, "go", "http"), want: 2 * MarkerWeight},
+		{name: "Section Test", other: article("Baking bread", "tutorials", bob), want: SectionWeight},
+		{name: "Creator Test", other: article("Baking bread", "food", ada), want: CreatorWeight},
+		{name: "Text Test", other: article("Writing a Go web server", "food", bob), want: TextWeight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(&base.PostBase, &tt.other.PostBase); got != tt.want {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	ada, bob := uuid.NewV4(), uuid.NewV4()
+	target := article("Writing a Go web server", "tutorials", ada, "go")
+	best := article("Testing a Go web server", "tutorials", ada, "go")
+	good := article("Go modules", "notes", bob, "go")
+	none := article("Baking bread", "food", bob, "baking")
+
+	got := Rank(target, []model.Post{none, good, target, best}, 5)
+	if len(got) != 2 || got[0] != model.Post(best) || got[1] != model.Post(good) {
+		t.Errorf("Rank() = %v, want best then good", got)
+	}
+
+	if got := Rank(target, []model.Post{good, best}, 1); len(got) != 1 {
+		t.Errorf("len(Rank()) = %v, want 1", len(got))
+	}
+}
+
+func TestStart(t *testing.T) {
+	Start()
+	for _, typ := range []event.Type{event.UserDeleted, event.UserRestored, event.UserErased} {
+		mu.Lock()
+		cache[uuid.NewV4()] = []model.Post{}
+		mu.Unlock()
+
+		event.Publish(event.New(typ, uuid.NewV4(), uuid.NewV4()))
+
+		mu.RLock()
+		n := len(cache)
+		mu.RUnlock()
+		if n != 0 {
+			t.Errorf("cache after %v has %d entries, want none", typ, n)
+		}
+	}
+}
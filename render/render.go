@@ -0,0 +1,99 @@
+package render
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Format describes how a piece of user content is written.
+type Format string
+
+// Formats supported for user content.
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatPlain    Format = "plain"
+)
+
+// wordsPerMinute is the average adult silent reading speed.
+const wordsPerMinute = 200
+
+var (
+	policy     = newPolicy()
+	strict     = bluemonday.StrictPolicy().AddSpaceWhenStrippingTag(true)
+	paragraphs = regexp.MustCompile(`\n\s*\n`)
+)
+
+// newPolicy builds the allowlist applied to all rendered HTML.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Valid reports whether f is a supported Format.
+// The empty Format is valid and treated as FormatMarkdown.
+func Valid(f Format) bool {
+	switch f {
+	case "", FormatMarkdown, FormatHTML, FormatPlain:
+		return true
+	}
+	return false
+}
+
+// HTML renders text written in format f to sanitised HTML.
+func HTML(text string, f Format) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	var out []byte
+	switch f {
+	case FormatHTML:
+		out = []byte(text)
+	case FormatPlain:
+		out = []byte(plain(text))
+	default:
+		out = blackfriday.Run([]byte(text))
+	}
+
+	return string(policy.SanitizeBytes(out))
+}
+
+// plain escapes text and wraps it in paragraphs, preserving line breaks.
+func plain(text string) string {
+	var b strings.Builder
+	for _, p := range paragraphs.Split(strings.TrimSpace(text), -1) {
+		lines := strings.Split(html.EscapeString(strings.TrimSpace(p)), "\n")
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>\n")
+	}
+	return b.String()
+}
+
+// Text strips all markup from rendered HTML, leaving readable text.
+func Text(rendered string) string {
+	return html.UnescapeString(strict.Sanitize(rendered))
+}
+
+// Words counts the words in text.
+func Words(text string) int {
+	return len(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'' && r != '-')
+	}))
+}
+
+// ReadingTime estimates the minutes needed to read words words.
+// Any non-empty text takes at least a minute.
+func ReadingTime(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	type args struct {
+		text   string
+		format Format
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []string
+		wantNone []string
+	}{
+		{
+			name: "Markdown Render Test",
+			args: args{text: "# Title\n\nSome *emphasis*.", format: FormatMarkdown},
+			want: []string{"<h1>Title</h1>", "<em>emphasis</em>"},
+		},
+		{
+			name:     "Markdown Sanitise Test",
+			args:     args{text: "Hi <script>alert(1)</script> [x](javascript:alert(1))", format: FormatMarkdown},
+			wantNone: []string{"<script", "javascript:"},
+		},
+		{
+			name:     "HTML Sanitise Test",
+			args:     args{text: `<p onclick="steal()">Hello</p><iframe src="x"></iframe>`, format: FormatHTML},
+			want:     []string{"<p>Hello</p>"},
+			wantNone: []string{"onclick", "<iframe"},
+		},
+		{
+			name:     "Plain Escape Test",
+			args:     args{text: "a <b>\nc\n\nd", format: FormatPlain},
+			want:     []string{"<p>a &lt;b&gt;<br>c</p>", "<p>d</p>"},
+			wantNone: []string{"<b>"},
+		},
+		{
+			name: "Link Rel Test",
+			args: args{text: "[yap](https://example.com)", format: FormatMarkdown},
+			want: []string{`rel="nofollow noopener"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HTML(tt.args.text, tt.args.format)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("HTML() = %v, want to contain %v", got, w)
+				}
+			}
+			for _, w := range tt.wantNone {
+				if strings.Contains(got, w) {
+					t.Errorf("HTML() = %v, want not to contain %v", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "Empty Text Test", text: "", want: 0},
+		{name: "Punctuation Test", text: "Hello, world! It's a well-known phrase.", want: 6},
+		{name: "Rendered Text Test", text: Text(HTML("# One\n\ntwo *three*", FormatMarkdown)), want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Words(tt.text); got != tt.want {
+				t.Errorf("Words() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		words int
+		want  int
+	}{
+		{name: "No Words Test", words: 0, want: 0},
+		{name: "Short Text Test", words: 1, want: 1},
+		{name: "Exact Minute Test", words: 400, want: 2},
+		{name: "Partial Minute Test", words: 401, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadingTime(tt.words); got != tt.want {
+				t.Errorf("ReadingTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest slug Make will return, in characters.
+const MaxLength = 80
+
+// Make turns s into a lowercase, hyphen-separated URL slug.
+// Accents are folded to their base letters and anything that is not
+// a letter or digit becomes a single hyphen.
+func Make(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			hyphen = false
+		default:
+			hyphen = true
+		}
+	}
+
+	out := []rune(b.String())
+	if len(out) > MaxLength {
+		cut := string(out[:MaxLength])
+		if i := strings.LastIndex(cut, "-"); out[MaxLength] != '-' && i > 0 {
+			cut = cut[:i]
+		}
+		return cut
+	}
+	return string(out)
+}
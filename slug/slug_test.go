@@ -0,0 +1,39 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "Simple Subject Test", s: "Hello World", want: "hello-world"},
+		{name: "Punctuation Test", s: "  Go: the -- Good Parts!  ", want: "go-the-good-parts"},
+		{name: "Accent Folding Test", s: "Crème Brûlée à la Française", want: "creme-brulee-a-la-francaise"},
+		{name: "Digits Test", s: "Top 10 tips (2019)", want: "top-10-tips-2019"},
+		{name: "Empty Subject Test", s: "?!", want: ""},
+		{
+			name: "Length Limit Test",
+			s:    "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen",
+			want: "one-two-three-four-five-six-seven-eight-nine-ten-eleven-twelve-thirteen-fourteen",
+		},
+		{name: "Cyrillic Test", s: "Привет, мир", want: "привет-мир"},
+		{
+			name: "Rune Length Limit Test",
+			s:    strings.Repeat("日本語 ", 25),
+			want: strings.TrimSuffix(strings.Repeat("日本語-", 20), "-"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Make(tt.s); got != tt.want {
+				t.Errorf("Make() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/l3njo/yap/event"
+)
+
+// Buffer is how many events a Subscriber may fall behind by before it is
+// dropped as a slow consumer.
+const Buffer = 64
+
+// Filter picks the events a Subscriber receives
+type Filter func(event.Event) bool
+
+// Subscriber receives matching events on C until it is closed.
+// Dropped is closed when the Subscriber fell more than Buffer events behind.
+type Subscriber struct {
+	C       <-chan event.Event
+	Dropped <-chan struct{}
+
+	c       chan event.Event
+	dropped chan struct{}
+	filter  Filter
+	once    sync.Once
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = map[*Subscriber]struct{}{}
+	start       sync.Once
+)
+
+// Start feeds published events to Subscribers. It is safe to call more than once.
+func Start() {
+	start.Do(func() {
+		event.Subscribe(Dispatch)
+	})
+}
+
+// Subscribe registers a Subscriber for events matching f
+func Subscribe(f Filter) *Subscriber {
+	c, dropped := make(chan event.Event, Buffer), make(chan struct{})
+	s := &Subscriber{C: c, Dropped: dropped, c: c, dropped: dropped, filter: f}
+
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers[s] = struct{}{}
+	return s
+}
+
+// Close unregisters s. It is safe to call more than once.
+func (s *Subscriber) Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(subscribers, s)
+}
+
+// drop unregisters s and tells it why; callers hold mu
+func (s *Subscriber) drop() {
+	delete(subscribers, s)
+	s.once.Do(func() { close(s.dropped) })
+}
+
+// Dispatch hands e to every matching Subscriber without blocking,
+// dropping those whose buffer is full.
+func Dispatch(e event.Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	for s := range subscribers {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			s.drop()
+		}
+	}
+}
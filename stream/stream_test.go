@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/l3njo/yap/event"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestDispatch_Filter(t *testing.T) {
+	s := Subscribe(func(e event.Event) bool { return e.Type == event.ReactionCreated })
+	defer s.Close()
+
+	Dispatch(event.New(event.PostPublished, uuid.Nil, nil))
+	Dispatch(event.New(event.ReactionCreated, uuid.Nil, nil))
+
+	if got := len(s.C); got != 1 {
+		t.Fatalf("len(C) = %v, want 1", got)
+	}
+	if e := <-s.C; e.Type != event.ReactionCreated {
+		t.Errorf("Type = %v, want %v", e.Type, event.ReactionCreated)
+	}
+}
+
+func TestDispatch_SlowConsumer(t *testing.T) {
+	slow := Subscribe(nil)
+	defer slow.Close()
+	fast := Subscribe(nil)
+	defer fast.Close()
+
+	for i := 0; i <= Buffer; i++ {
+		Dispatch(event.New(event.PostPublished, uuid.Nil, nil))
+		if i < Buffer {
+			<-fast.C
+		}
+	}
+
+	select {
+	case <-slow.Dropped:
+	default:
+		t.Fatal("slow subscriber was not dropped")
+	}
+	select {
+	case <-fast.Dropped:
+		t.Fatal("fast subscriber was dropped")
+	default:
+	}
+
+	Dispatch(event.New(event.PostPublished, uuid.Nil, nil))
+	if got := len(slow.C); got != Buffer {
+		t.Errorf("len(C) after drop = %v, want %v", got, Buffer)
+	}
+}
+
+func TestSubscriber_Close(t *testing.T) {
+	s := Subscribe(nil)
+	s.Close()
+	s.Close()
+
+	Dispatch(event.New(event.PostPublished, uuid.Nil, nil))
+	if got := len(s.C); got != 0 {
+		t.Errorf("len(C) after Close = %v, want 0", got)
+	}
+}
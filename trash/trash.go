@@ -0,0 +1,64 @@
+package trash
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/l3njo/yap/model"
+)
+
+// DefaultRetention is how long deleted things stay in the trash
+// when TRASH_RETENTION is not set
+const DefaultRetention = 30 * 24 * time.Hour
+
+// interval is how often the trash is purged
+const interval = time.Hour
+
+// ParseRetention reads a retention period such as "30d" or "36h".
+// An empty s is DefaultRetention.
+func ParseRetention(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultRetention, nil
+	}
+
+	var d time.Duration
+	var err error
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("trash: retention %q is not a positive duration", s)
+	}
+	return d, nil
+}
+
+// Start purges everything deleted more than retention ago,
+// every interval until the process exits.
+func Start(retention time.Duration) {
+	go func() {
+		Purge(retention)
+		for range time.Tick(interval) {
+			Purge(retention)
+		}
+	}()
+}
+
+// Purge removes for good everything deleted more than retention ago
+func Purge(retention time.Duration) {
+	counts, err := model.PurgeTrash(time.Now().UTC().Add(-retention))
+	if err != nil {
+		log.Println("trash: purge:", err)
+		return
+	}
+
+	if counts != (model.PurgeCounts{}) {
+		log.Printf("trash: purged %d posts, %d reactions and %d users", counts.Posts, counts.Reactions, counts.Users)
+	}
+}
@@ -0,0 +1,34 @@
+package trash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "Default Test", in: "", want: DefaultRetention},
+		{name: "Days Test", in: "7d", want: 7 * 24 * time.Hour},
+		{name: "Duration Test", in: "36h", want: 36 * time.Hour},
+		{name: "Zero Test", in: "0d", wantErr: true},
+		{name: "Negative Test", in: "-1h", wantErr: true},
+		{name: "Garbage Test", in: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetention(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRetention() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRetention() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package workflow
+
+// State is where a post is in the editorial workflow
+type State string
+
+// States of the editorial workflow
+const (
+	Draft     State = "draft"
+	InReview  State = "in_review"
+	Approved  State = "approved"
+	Published State = "published"
+	Archived  State = "archived"
+)
+
+// Action moves a post from one State to another
+type Action string
+
+// Actions of the editorial workflow
+const (
+	Submit         Action = "submit"
+	Approve        Action = "approve"
+	RequestChanges Action = "request_changes"
+	Publish        Action = "publish"
+	Retract        Action = "retract"
+	Archive        Action = "archive"
+	Unarchive      Action = "unarchive"
+)
+
+// transition is the States an Action applies to and the State it leads to
+type transition struct {
+	from []State
+	to   State
+}
+
+var transitions = map[Action]transition{
+	Submit:         {from: []State{Draft}, to: InReview},
+	Approve:        {from: []State{InReview}, to: Approved},
+	RequestChanges: {from: []State{InReview, Approved}, to: Draft},
+	Publish:        {from: []State{Approved}, to: Published},
+	Retract:        {from: []State{Published}, to: Draft},
+	Archive:        {from: []State{Draft, Published}, to: Archived},
+	Unarchive:      {from: []State{Archived}, to: Draft},
+}
+
+// Next returns the State a post in from moves to when a is taken,
+// and whether a may be taken from there at all.
+func Next(from State, a Action) (State, bool) {
+	t, ok := transitions[a]
+	if !ok {
+		return from, false
+	}
+
+	for _, s := range t.from {
+		if s == from {
+			return t.to, true
+		}
+	}
+	return from, false
+}
+
+// Target returns the State a leads to, or "" if a is not an Action
+func Target(a Action) State {
+	return transitions[a].to
+}
+
+// Actions lists the Actions that may be taken from s
+func Actions(s State) []Action {
+	var out []Action
+	for _, a := range []Action{Submit, Approve, RequestChanges, Publish, Retract, Archive, Unarchive} {
+		if _, ok := Next(s, a); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
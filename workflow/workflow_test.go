@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   State
+		action Action
+		want   State
+		wantOK bool
+	}{
+		{name: "Submit Test", from: Draft, action: Submit, want: InReview, wantOK: true},
+		{name: "Approve Test", from: InReview, action: Approve, want: Approved, wantOK: true},
+		{name: "Request Changes Test", from: InReview, action: RequestChanges, want: Draft, wantOK: true},
+		{name: "Publish Test", from: Approved, action: Publish, want: Published, wantOK: true},
+		{name: "Publish Draft Test", from: Draft, action: Publish, want: Draft},
+		{name: "Publish In Review Test", from: InReview, action: Publish, want: InReview},
+		{name: "Retract Test", from: Published, action: Retract, want: Draft, wantOK: true},
+		{name: "Archive Test", from: Published, action: Archive, want: Archived, wantOK: true},
+		{name: "Approve Archived Test", from: Archived, action: Approve, want: Archived},
+		{name: "Unknown Action Test", from: Draft, action: "launch", want: Draft},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Next(tt.from, tt.action)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestActions(t *testing.T) {
+	if got, want := Actions(InReview), []Action{Approve, RequestChanges}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Actions() = %v, want %v", got, want)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	if got := Target(Publish); got != Published {
+		t.Errorf("Target() = %v, want %v", got, Published)
+	}
+	if got := Target("launch"); got != "" {
+		t.Errorf("Target() = %v, want none", got)
+	}
+}